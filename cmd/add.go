@@ -25,15 +25,27 @@ var addCmd = &cobra.Command{
 }
 
 type addCmdConfig struct {
-	force   bool
-	prompt  bool
-	options chezmoi.AddOptions
+	allowSecrets bool
+	force        bool
+	prompt       bool
+	options      chezmoi.AddOptions
+}
+
+type autoTemplateConfig struct {
+	WordBoundary string
+	Exclude      []string
+}
+
+type secretScanConfig struct {
+	Patterns   []string
+	MinEntropy float64
 }
 
 func init() {
 	rootCmd.AddCommand(addCmd)
 
 	persistentFlags := addCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.add.allowSecrets, "allow-secrets", false, "add files that may contain secrets without encrypting them")
 	persistentFlags.BoolVarP(&config.add.options.Empty, "empty", "e", false, "add empty files")
 	persistentFlags.BoolVar(&config.add.options.Encrypt, "encrypt", false, "encrypt files")
 	persistentFlags.BoolVarP(&config.add.force, "force", "f", false, "overwrite source state, even if template would be lost")
@@ -50,6 +62,10 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 	// Make --autotemplate imply --template.
 	if c.add.options.AutoTemplate {
 		c.add.options.Template = true
+		c.add.options.AutoTemplateOptions, err = c.getAutoTemplateOptions()
+		if err != nil {
+			return err
+		}
 	}
 
 	ts, err := c.getTargetState(nil)
@@ -59,6 +75,13 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 	if err := c.ensureSourceDirectory(); err != nil {
 		return err
 	}
+	var secretScanner *chezmoi.SecretScanner
+	if !c.add.allowSecrets && !c.add.options.Encrypt {
+		secretScanner, err = c.getSecretScanner()
+		if err != nil {
+			return err
+		}
+	}
 	destDirPrefix := filepath.FromSlash(ts.DestDir + "/")
 	var quit int // quit is an int with a unique address
 	defer func() {
@@ -109,7 +132,16 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 						c.add.prompt = false
 					}
 				}
-				return ts.Add(c.fs, c.add.options, path, info, c.Follow, c.mutator)
+				if err := c.checkSecrets(secretScanner, ts, path, info); err != nil {
+					return err
+				}
+				if err := c.previewAutoTemplate(ts, path, info); err != nil {
+					return err
+				}
+				if err := ts.Add(c.fs, c.add.options, path, info, c.Follow, c.mutator); err != nil {
+					return err
+				}
+				return c.setMergeBase(strings.TrimPrefix(path, destDirPrefix), nil)
 			}); err != nil {
 				return err
 			}
@@ -143,10 +175,112 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 					c.add.prompt = false
 				}
 			}
+			if err := c.checkSecrets(secretScanner, ts, path, nil); err != nil {
+				return err
+			}
+			if err := c.previewAutoTemplate(ts, path, nil); err != nil {
+				return err
+			}
 			if err := ts.Add(c.fs, c.add.options, path, nil, c.Follow, c.mutator); err != nil {
 				return err
 			}
+			if err := c.setMergeBase(strings.TrimPrefix(path, destDirPrefix), nil); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
+
+// checkSecrets returns an error if the file at path may contain secrets
+// according to secretScanner. If secretScanner is nil then no files are
+// checked. With --autotemplate, the file's contents are checked after the
+// values of ts's template data are replaced, as only they are added.
+func (c *Config) checkSecrets(secretScanner *chezmoi.SecretScanner, ts *chezmoi.TargetState, path string, info os.FileInfo) error {
+	if secretScanner == nil {
+		return nil
+	}
+	if info == nil || c.Follow && info.Mode()&os.ModeType == os.ModeSymlink {
+		var err error
+		if c.Follow {
+			info, err = c.fs.Stat(path)
+		} else {
+			info, err = c.fs.Lstat(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	data, err := c.fs.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if c.add.options.AutoTemplate {
+		data, _ = chezmoi.AutoTemplate(data, ts.TemplateData, c.add.options.AutoTemplateOptions)
+	}
+	findings := secretScanner.Scan(data)
+	if len(findings) == 0 {
+		return nil
+	}
+	findingStrs := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		findingStrs = append(findingStrs, finding.String())
+	}
+	return fmt.Errorf("%s: may contain secrets (%s), use --encrypt to encrypt it or --allow-secrets to add it anyway", path, strings.Join(findingStrs, ", "))
+}
+
+// previewAutoTemplate prints the substitutions that --autotemplate would make
+// in the file at path if --dry-run is set.
+func (c *Config) previewAutoTemplate(ts *chezmoi.TargetState, path string, info os.FileInfo) error {
+	if !c.DryRun || !c.add.options.AutoTemplate {
+		return nil
+	}
+	if info == nil || c.Follow && info.Mode()&os.ModeType == os.ModeSymlink {
+		var err error
+		if c.Follow {
+			info, err = c.fs.Stat(path)
+		} else {
+			info, err = c.fs.Lstat(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	data, err := c.fs.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, substitutions := chezmoi.AutoTemplate(data, ts.TemplateData, c.add.options.AutoTemplateOptions)
+	redactor := c.getRedactor()
+	for _, substitution := range substitutions {
+		fmt.Fprintf(c.Stdout, "%s:%d: %s -> {{ .%s }}\n", path, substitution.Line, redactor.RedactString(substitution.Value), substitution.Name)
+	}
+	return nil
+}
+
+// getAutoTemplateOptions returns the autotemplate options configured by c.
+func (c *Config) getAutoTemplateOptions() (chezmoi.AutoTemplateOptions, error) {
+	wordBoundary, err := chezmoi.ParseWordBoundary(c.AutoTemplate.WordBoundary)
+	if err != nil {
+		return chezmoi.AutoTemplateOptions{}, fmt.Errorf("autoTemplate.wordBoundary: %w", err)
+	}
+	return chezmoi.AutoTemplateOptions{
+		WordBoundary: wordBoundary,
+		Exclude:      c.AutoTemplate.Exclude,
+	}, nil
+}
+
+// getSecretScanner returns a new SecretScanner configured by c.
+func (c *Config) getSecretScanner() (*chezmoi.SecretScanner, error) {
+	secretScanner, err := chezmoi.NewSecretScanner(c.SecretScan.Patterns, c.SecretScan.MinEntropy)
+	if err != nil {
+		return nil, fmt.Errorf("secretScan.patterns: %w", err)
+	}
+	return secretScanner, nil
+}
@@ -1,7 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var applyCmd = &cobra.Command{
@@ -13,9 +19,17 @@ var applyCmd = &cobra.Command{
 	RunE:    config.runApplyCmd,
 }
 
+type applyCmdConfig struct {
+	interactive bool
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
 
+	persistentFlags := applyCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.apply.interactive, "interactive", false, "prompt before applying each change")
+	addIncludeExcludeFlags(applyCmd)
+
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
 }
 
@@ -26,5 +40,41 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
+	if c.apply.interactive {
+		mutator := c.mutator
+		c.mutator = chezmoi.NewInteractiveMutator(mutator, c.Stdout, c.colored, c.maxDiffDataSize, c.getRedactor(), c.prompt, func(targetPath string) error {
+			return c.mergeTarget(cmd, mutator, targetPath)
+		})
+	}
+
 	return c.applyArgs(args, persistentState)
 }
+
+// mergeTarget runs the merge command on targetPath, using mutator to run it.
+func (c *Config) mergeTarget(cmd *cobra.Command, mutator chezmoi.Mutator, targetPath string) error {
+	interactiveMutator := c.mutator
+	c.mutator = mutator
+	defer func() {
+		c.mutator = interactiveMutator
+	}()
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	entry, err := ts.Get(c.fs, targetPath)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("%s: not in source state", targetPath)
+	}
+
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	return c.runMergeCommand(cmd, ts, targetPath, entry, tempDir)
+}
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type archiveCmdConfig struct {
@@ -28,17 +30,26 @@ func init() {
 	persistentFlags := archiveCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.archive.output, "output", "o", "", "output filename")
 	panicOnError(archiveCmd.MarkPersistentFlagFilename("output"))
+	addIncludeExcludeFlags(archiveCmd)
+	addProfileFlags(archiveCmd)
 }
 
 func (c *Config) runArchiveCmd(cmd *cobra.Command, args []string) error {
+	include, err := chezmoi.NewIncludeSet(c.include, c.exclude)
+	if err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
+	if err := ts.EvaluateConcurrently(include, c.Parallelism); err != nil {
+		return err
+	}
 
 	output := &strings.Builder{}
 	w := tar.NewWriter(output)
-	if err := ts.Archive(w, os.FileMode(c.Umask)); err != nil {
+	if err := ts.Archive(w, include, os.FileMode(c.Umask)); err != nil {
 		return err
 	}
 	if err := w.Close(); err != nil {
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var backupsCmd = &cobra.Command{
+	Use:     "backups",
+	Args:    cobra.NoArgs,
+	Short:   "Manage backups of overwritten and removed targets",
+	Long:    mustGetLongHelp("backups"),
+	Example: getExample("backups"),
+}
+
+var backupsListCmd = &cobra.Command{
+	Use:     "list [backup]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "List backups, or the targets in a backup",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runBackupsListCmd,
+}
+
+var backupsRestoreCmd = &cobra.Command{
+	Use:     "restore backup [targets...]",
+	Args:    cobra.MinimumNArgs(1),
+	Short:   "Restore targets from a backup",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runBackupsRestoreCmd,
+}
+
+var backupsPruneCmd = &cobra.Command{
+	Use:     "prune",
+	Args:    cobra.NoArgs,
+	Short:   "Remove old backups",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runBackupsPruneCmd,
+}
+
+type backupConfig struct {
+	Dir string
+}
+
+type backupsCmdConfig struct {
+	keep int
+}
+
+var errNoBackupDir = errors.New("backup.dir not set")
+
+func init() {
+	rootCmd.AddCommand(backupsCmd)
+	backupsCmd.AddCommand(backupsListCmd)
+	backupsCmd.AddCommand(backupsRestoreCmd)
+	backupsCmd.AddCommand(backupsPruneCmd)
+
+	flags := backupsPruneCmd.Flags()
+	flags.IntVar(&config.backups.keep, "keep", 10, "number of backups to keep")
+}
+
+func (c *Config) runBackupsListCmd(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		backups, err := c.getBackups()
+		if err != nil {
+			return err
+		}
+		for _, backup := range backups {
+			if _, err := fmt.Fprintln(c.Stdout, backup); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	backupDir, err := c.getBackupDir(args[0])
+	if err != nil {
+		return err
+	}
+	return vfs.Walk(c.fs, backupDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		relPath, err := filepath.Rel(backupDir, path)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(c.Stdout, filepath.Join(c.DestDir, relPath))
+		return err
+	})
+}
+
+func (c *Config) runBackupsRestoreCmd(cmd *cobra.Command, args []string) error {
+	backupDir, err := c.getBackupDir(args[0])
+	if err != nil {
+		return err
+	}
+
+	var relPaths []string
+	for _, arg := range args[1:] {
+		targetPath, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(c.DestDir, targetPath)
+		if err != nil {
+			return err
+		}
+		if relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: not in destination directory (%s)", arg, c.DestDir)
+		}
+		relPaths = append(relPaths, relPath)
+	}
+
+	found := make(map[string]bool)
+	if err := vfs.Walk(c.fs, backupDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(backupDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if len(relPaths) != 0 {
+			selected := false
+			for _, p := range relPaths {
+				switch {
+				case relPath == p || strings.HasPrefix(relPath, p+string(filepath.Separator)):
+					found[p] = true
+					selected = true
+				case strings.HasPrefix(p, relPath+string(filepath.Separator)):
+					// path is a parent of a selected target, so restore it
+					// only if it is a directory.
+					selected = info.IsDir()
+				}
+				if selected {
+					break
+				}
+			}
+			if !selected {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		targetPath := filepath.Join(c.DestDir, relPath)
+		if info.IsDir() {
+			return vfs.MkdirAll(c.mutator, targetPath, info.Mode().Perm())
+		}
+		if err := c.mutator.RemoveAll(targetPath); err != nil {
+			return err
+		}
+		return chezmoi.CopyAll(c.fs, c.mutator, path, targetPath)
+	}); err != nil {
+		return err
+	}
+
+	for i, relPath := range relPaths {
+		if !found[relPath] {
+			return fmt.Errorf("%s: not in backup %s", args[i+1], args[0])
+		}
+	}
+	return nil
+}
+
+func (c *Config) runBackupsPruneCmd(cmd *cobra.Command, args []string) error {
+	if c.backups.keep < 0 {
+		return fmt.Errorf("invalid --keep value: %d", c.backups.keep)
+	}
+	backups, err := c.getBackups()
+	if err != nil {
+		return err
+	}
+	if len(backups) <= c.backups.keep {
+		return nil
+	}
+	for _, backup := range backups[:len(backups)-c.backups.keep] {
+		if err := c.mutator.RemoveAll(filepath.Join(c.Backup.Dir, backup)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ignoreBackup returns true if targetPath should not be backed up, because it
+// is in the source or backup directories.
+func (c *Config) ignoreBackup(targetPath string) bool {
+	for _, dir := range []string{c.SourceDir, c.Backup.Dir} {
+		if targetPath == dir || strings.HasPrefix(targetPath, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getBackupDir returns the directory of backup.
+func (c *Config) getBackupDir(backup string) (string, error) {
+	if c.Backup.Dir == "" {
+		return "", errNoBackupDir
+	}
+	if !isBackupName(backup) {
+		return "", fmt.Errorf("%s: invalid backup name", backup)
+	}
+	backupDir := filepath.Join(c.Backup.Dir, backup)
+	info, err := c.fs.Stat(backupDir)
+	switch {
+	case os.IsNotExist(err):
+		return "", fmt.Errorf("%s: backup not found", backup)
+	case err != nil:
+		return "", err
+	case !info.IsDir():
+		return "", fmt.Errorf("%s: not a backup", backup)
+	}
+	return backupDir, nil
+}
+
+// getBackups returns the names of all backups, oldest first.
+func (c *Config) getBackups() ([]string, error) {
+	if c.Backup.Dir == "" {
+		return nil, errNoBackupDir
+	}
+	infos, err := c.fs.ReadDir(c.Backup.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, info := range infos {
+		if info.IsDir() && isBackupName(info.Name()) {
+			backups = append(backups, info.Name())
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// isBackupName returns true if name is a valid backup name, i.e. a time in
+// chezmoi.BackupTimeFormat. This prevents names like ../foo from referring to
+// directories outside the backup directory.
+func isBackupName(name string) bool {
+	_, err := time.Parse(chezmoi.BackupTimeFormat, name)
+	return err == nil
+}
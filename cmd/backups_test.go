@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestBackupsCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".backups": map[string]interface{}{
+				"20200101T000000Z/.bashrc": "# oldest .bashrc\n",
+				"20200201T000000Z": map[string]interface{}{
+					".bashrc":         "# old .bashrc\n",
+					".config/foo/bar": "# old bar\n",
+					".inputrc":        "# old .inputrc\n",
+				},
+				"20200301T000000Z/.bashrc": "# newest .bashrc\n",
+				"keep/.bashrc":             "# not a backup\n",
+			},
+			".bashrc":  "# current .bashrc\n",
+			".inputrc": "# current .inputrc\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &strings.Builder{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.Backup.Dir = "/home/user/.backups"
+
+	require.NoError(t, c.runBackupsListCmd(nil, nil))
+	assert.Equal(t, "20200101T000000Z\n20200201T000000Z\n20200301T000000Z\n", stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, c.runBackupsListCmd(nil, []string{"20200201T000000Z"}))
+	assert.Equal(t, "/home/user/.bashrc\n/home/user/.config/foo/bar\n/home/user/.inputrc\n", stdout.String())
+
+	require.NoError(t, c.runBackupsRestoreCmd(nil, []string{"20200201T000000Z", "/home/user/.config"}))
+	assert.Error(t, c.runBackupsRestoreCmd(nil, []string{"20200201T000000Z", "/home/user/.zshrc"}))
+	assert.Error(t, c.runBackupsRestoreCmd(nil, []string{"20200401T000000Z"}))
+	assert.Error(t, c.runBackupsListCmd(nil, []string{"keep"}))
+	assert.Error(t, c.runBackupsListCmd(nil, []string{".."}))
+	assert.Error(t, c.runBackupsRestoreCmd(nil, []string{"../.."}))
+	assert.Error(t, c.runBackupsRestoreCmd(nil, []string{"20200201T000000Z/.config"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# current .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.config/foo/bar",
+			vfst.TestContentsString("# old bar\n"),
+		),
+		vfst.TestPath("/home/user/.inputrc",
+			vfst.TestContentsString("# current .inputrc\n"),
+		),
+	)
+
+	require.NoError(t, c.runBackupsRestoreCmd(nil, []string{"20200201T000000Z"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# old .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.inputrc",
+			vfst.TestContentsString("# old .inputrc\n"),
+		),
+	)
+
+	c.backups.keep = -1
+	assert.Error(t, c.runBackupsPruneCmd(nil, nil))
+
+	c.backups.keep = 1
+	require.NoError(t, c.runBackupsPruneCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.backups/20200101T000000Z",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.backups/20200201T000000Z",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.backups/20200301T000000Z",
+			vfst.TestIsDir,
+		),
+		vfst.TestPath("/home/user/.backups/keep/.bashrc",
+			vfst.TestContentsString("# not a backup\n"),
+		),
+	)
+}
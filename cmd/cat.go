@@ -21,10 +21,15 @@ var catCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(catCmd)
 
+	addProfileFlags(catCmd)
+	addTraceTemplatesFlag(catCmd)
+
 	markRemainingZshCompPositionalArgumentsAsFiles(catCmd, 1)
 }
 
 func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
+	defer c.writeTemplateTrace()
+
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
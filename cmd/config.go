@@ -12,7 +12,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 	"unicode"
@@ -43,65 +45,112 @@ type sourceVCSConfig struct {
 	Pull       interface{}
 }
 
+type outputConfig struct {
+	Timeout time.Duration
+}
+
 type templateConfig struct {
 	Options []string
 }
 
+type escalateConfig struct {
+	Command string
+	Args    []string
+	Paths   []string
+}
+
 // A Config represents a configuration.
 type Config struct {
-	configFile        string
-	err               error
-	fs                vfs.FS
-	mutator           chezmoi.Mutator
-	SourceDir         string
-	DestDir           string
-	Umask             permValue
-	DryRun            bool
-	Follow            bool
-	Remove            bool
-	Verbose           bool
-	Color             string
-	Debug             bool
-	GPG               chezmoi.GPG
-	GPGRecipient      string
-	SourceVCS         sourceVCSConfig
-	Template          templateConfig
-	Merge             mergeConfig
-	Bitwarden         bitwardenCmdConfig
-	CD                cdCmdConfig
-	Diff              diffCmdConfig
-	GenericSecret     genericSecretCmdConfig
-	Gopass            gopassCmdConfig
-	KeePassXC         keePassXCCmdConfig
-	Lastpass          lastpassCmdConfig
-	Onepassword       onepasswordCmdConfig
-	Vault             vaultCmdConfig
-	Pass              passCmdConfig
-	Data              map[string]interface{}
-	colored           bool
-	maxDiffDataSize   int
-	templateFuncs     template.FuncMap
-	add               addCmdConfig
-	archive           archiveCmdConfig
-	completion        completionCmdConfig
-	data              dataCmdConfig
-	dump              dumpCmdConfig
-	edit              editCmdConfig
-	executeTemplate   executeTemplateCmdConfig
-	_import           importCmdConfig
-	init              initCmdConfig
-	managed           managedCmdConfig
-	purge             purgeCmdConfig
-	remove            removeCmdConfig
-	update            updateCmdConfig
-	upgrade           upgradeCmdConfig
-	Stdin             io.Reader
-	Stdout            io.Writer
-	Stderr            io.Writer
-	bds               *xdg.BaseDirectorySpecification
-	scriptStateBucket []byte
+	configFile           string
+	err                  error
+	fs                   vfs.FS
+	mutator              chezmoi.Mutator
+	persistentState      chezmoi.PersistentState
+	persistentStateMutex sync.Mutex
+	secretCmdMutex       sync.Mutex
+	secretOutputCache    *secretCache
+	outputCache          *secretCache
+	targetState          *chezmoi.TargetState
+	SourceDir            string
+	DestDir              string
+	Umask                permValue
+	DryRun               bool
+	Follow               bool
+	Remove               bool
+	Verbose              bool
+	Parallelism          int
+	Color                string
+	Debug                bool
+	LogFile              string
+	LogFormat            string
+	Escalate             escalateConfig
+	Backup               backupConfig
+	SecretCache          secretCacheConfig
+	SecretProviders      map[string]customSecretProviderConfig
+	SecretScan           secretScanConfig
+	AutoTemplate         autoTemplateConfig
+	GPG                  chezmoi.GPG
+	GPGRecipient         string
+	SourceVCS            sourceVCSConfig
+	Template             templateConfig
+	Output               outputConfig
+	Merge                mergeConfig
+	Bitwarden            bitwardenCmdConfig
+	CD                   cdCmdConfig
+	Diff                 diffCmdConfig
+	GenericSecret        genericSecretCmdConfig
+	Gopass               gopassCmdConfig
+	KeePassXC            keePassXCCmdConfig
+	Lastpass             lastpassCmdConfig
+	Onepassword          onepasswordCmdConfig
+	Vault                vaultCmdConfig
+	Pass                 passCmdConfig
+	Sops                 sopsCmdConfig
+	Data                 map[string]interface{}
+	colored              bool
+	maxDiffDataSize      int
+	include              []string
+	exclude              []string
+	profile              string
+	setData              []string
+	traceTemplates       bool
+	templateTrace        *chezmoi.TemplateTrace
+	templateFuncs        template.FuncMap
+	secretTemplateFuncs  map[string]struct{}
+	redactor             *chezmoi.Redactor
+	showSecrets          bool
+	add                  addCmdConfig
+	apply                applyCmdConfig
+	archive              archiveCmdConfig
+	backups              backupsCmdConfig
+	completion           completionCmdConfig
+	data                 dataCmdConfig
+	dump                 dumpCmdConfig
+	edit                 editCmdConfig
+	executeTemplate      executeTemplateCmdConfig
+	_import              importCmdConfig
+	init                 initCmdConfig
+	lint                 lintCmdConfig
+	managed              managedCmdConfig
+	merge                mergeCmdConfig
+	purge                purgeCmdConfig
+	remove               removeCmdConfig
+	update               updateCmdConfig
+	upgrade              upgradeCmdConfig
+	watch                watchCmdConfig
+	Stdin                io.Reader
+	Stdout               io.Writer
+	Stderr               io.Writer
+	logFile              io.WriteCloser
+	bds                  *xdg.BaseDirectorySpecification
+	mergeBaseBucket      []byte
+	scriptStateBucket    []byte
 }
 
+// sourceDataName is the prefix of files in the source directory that contain
+// template data.
+const sourceDataName = ".chezmoidata"
+
 // A configOption sets an option on a Config.
 type configOption func(*Config)
 
@@ -120,6 +169,25 @@ var (
 		},
 	}
 
+	unmarshalFormatMap = map[string]func([]byte) (interface{}, error){
+		"json": func(data []byte) (interface{}, error) {
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		},
+		"toml": func(data []byte) (interface{}, error) {
+			tree, err := toml.LoadBytes(data)
+			if err != nil {
+				return nil, err
+			}
+			return tree.ToMap(), nil
+		},
+		"yaml": unmarshalYAML,
+		"yml":  unmarshalYAML,
+	}
+
 	wellKnownAbbreviations = map[string]struct{}{
 		"ANSI": {},
 		"CPE":  {},
@@ -135,25 +203,43 @@ var (
 // newConfig creates a new Config with the given options.
 func newConfig(options ...configOption) *Config {
 	c := &Config{
-		Umask: permValue(getUmask()),
-		Color: "auto",
+		Umask:       permValue(getUmask()),
+		Color:       "auto",
+		LogFormat:   "json",
+		Parallelism: runtime.NumCPU(),
 		SourceVCS: sourceVCSConfig{
 			Command: "git",
 		},
 		Template: templateConfig{
 			Options: chezmoi.DefaultTemplateOptions,
 		},
+		Output: outputConfig{
+			Timeout: time.Minute,
+		},
 		Diff: diffCmdConfig{
 			Format: "chezmoi",
 		},
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
+		Escalate: escalateConfig{
+			Command: "sudo",
+		},
+		SecretScan: secretScanConfig{
+			MinEntropy: chezmoi.DefaultSecretScannerMinEntropy,
+		},
+		AutoTemplate: autoTemplateConfig{
+			WordBoundary: string(chezmoi.WordBoundaryAlphanumeric),
+		},
 		GPG: chezmoi.GPG{
 			Command: "gpg",
 		},
 		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
+		secretOutputCache: newSecretCache(),
+		outputCache:       newSecretCache(),
+		redactor:          chezmoi.NewRedactor(),
 		templateFuncs:     sprig.TxtFuncMap(),
+		mergeBaseBucket:   []byte("mergeBase"),
 		scriptStateBucket: []byte("script"),
 		Stdin:             os.Stdin,
 		Stdout:            os.Stdout,
@@ -175,8 +261,54 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 	c.templateFuncs[key] = value
 }
 
+// getRedactor returns the redactor used to mask secrets in output, or nil if
+// secrets should be shown.
+func (c *Config) getRedactor() *chezmoi.Redactor {
+	if c.showSecrets {
+		return nil
+	}
+	return c.redactor
+}
+
+// writeTemplateTrace writes the report of template execution to c.Stderr, if
+// templates were traced.
+func (c *Config) writeTemplateTrace() {
+	if c.templateTrace == nil {
+		return
+	}
+	_ = c.templateTrace.WriteReport(c.Stderr)
+}
+
+// addSecretTemplateFunc adds a template function that returns secrets. The
+// values returned by secret template functions are not shown in traces and are
+// masked in output unless --show-secrets is given.
+func (c *Config) addSecretTemplateFunc(key string, value interface{}) {
+	c.addWrappedSecretTemplateFunc(key, c.redactor.WrapFunc(value))
+}
+
+// addPasswordManagerTemplateFunc adds a template function that returns
+// password manager items, as for addSecretTemplateFunc, except that the fields
+// that describe the items, like their names, are not masked.
+func (c *Config) addPasswordManagerTemplateFunc(key string, value interface{}) {
+	c.addWrappedSecretTemplateFunc(key, c.redactor.WrapItemFunc(value))
+}
+
+// addWrappedSecretTemplateFunc adds the secret template function value, which
+// already records its results with c.redactor.
+func (c *Config) addWrappedSecretTemplateFunc(key string, value interface{}) {
+	c.addTemplateFunc(key, value)
+	if c.secretTemplateFuncs == nil {
+		c.secretTemplateFuncs = make(map[string]struct{})
+	}
+	c.secretTemplateFuncs[key] = struct{}{}
+}
+
 func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
 	fs := vfs.NewReadOnlyFS(c.fs)
+	include, err := chezmoi.NewIncludeSet(c.include, c.exclude)
+	if err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -185,6 +317,7 @@ func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentStat
 		DestDir:           ts.DestDir,
 		DryRun:            c.DryRun,
 		Ignore:            ts.TargetIgnore.Match,
+		Include:           include,
 		PersistentState:   persistentState,
 		Remove:            c.Remove,
 		ScriptStateBucket: c.scriptStateBucket,
@@ -193,18 +326,49 @@ func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentStat
 		Verbose:           c.Verbose,
 	}
 	if len(args) == 0 {
-		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
+		if err := ts.EvaluateConcurrently(include, c.Parallelism); err != nil {
+			return err
+		}
+		if err := ts.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
+			return err
+		}
+		return c.recordMergeBases(ts, include, ts.AllEntries())
 	}
 	entries, err := c.getEntries(ts, args)
 	if err != nil {
 		return err
 	}
+	if err := chezmoi.EvaluateEntries(entries, ts.TargetIgnore.Match, include, c.Parallelism); err != nil {
+		return err
+	}
+	var allEntries []chezmoi.Entry
 	for _, entry := range entries {
 		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
 			return err
 		}
+		allEntries = entry.AppendAllEntries(allEntries)
 	}
-	return nil
+	return c.recordMergeBases(ts, include, allEntries)
+}
+
+// addIncludeExcludeFlags adds the --include and --exclude flags to cmd.
+func addIncludeExcludeFlags(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringSliceVarP(&config.include, "include", "i", []string{"all"}, "include entry types")
+	persistentFlags.StringSliceVarP(&config.exclude, "exclude", "x", nil, "exclude entry types")
+}
+
+// addProfileFlags adds flags to cmd to override the template data.
+func addProfileFlags(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.StringVar(&config.profile, "profile", "", "override template data with data from file")
+	persistentFlags.StringArrayVar(&config.setData, "set", nil, "override template data key with value")
+}
+
+// addTraceTemplatesFlag adds a flag to cmd to trace template execution.
+func addTraceTemplatesFlag(cmd *cobra.Command) {
+	persistentFlags := cmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.traceTemplates, "trace-templates", false, "print a report of template execution")
 }
 
 func (c *Config) autoCommit(vcs VCS) error {
@@ -313,8 +477,115 @@ func (c *Config) getData() (map[string]interface{}, error) {
 	data := map[string]interface{}{
 		"chezmoi": defaultData,
 	}
-	for key, value := range c.Data {
-		data[key] = value
+	if err := c.mergeSourceData(data); err != nil {
+		return nil, err
+	}
+	mergeData(data, c.Data)
+	if err := c.applyProfile(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// applyProfile overrides data with the data in c.profile and then with the
+// key=value pairs in c.setData. Keys are paths of map keys separated by dots,
+// so --set chezmoi.os=darwin makes templates render as if on macOS. Values are
+// strings, unless given as key:=value, in which case they are parsed as YAML.
+func (c *Config) applyProfile(data map[string]interface{}) error {
+	if c.profile != "" {
+		profileData, err := c.readDataFile(c.profile)
+		if err != nil {
+			return err
+		}
+		mergeData(data, profileData)
+	}
+	for _, keyValue := range c.setData {
+		index := strings.IndexByte(keyValue, '=')
+		if index <= 0 {
+			return fmt.Errorf("%s: invalid --set, expected key=value", keyValue)
+		}
+		key := keyValue[:index]
+		var value interface{} = keyValue[index+1:]
+		if strings.HasSuffix(key, ":") {
+			key = strings.TrimSuffix(key, ":")
+			var err error
+			if value, err = unmarshalYAML([]byte(keyValue[index+1:])); err != nil {
+				return fmt.Errorf("%s: %w", keyValue, err)
+			}
+		}
+		if key == "" {
+			return fmt.Errorf("%s: invalid --set, expected key=value", keyValue)
+		}
+		setDataValue(data, strings.Split(key, "."), value)
+	}
+	return nil
+}
+
+// mergeSourceData merges the data in the .chezmoidata.<format> files in the
+// source directory into data, in lexical order. Files named
+// .chezmoidata.sops.<format> are decrypted with sops first. Files with other
+// names, for example editor backup and swap files, are ignored.
+func (c *Config) mergeSourceData(data map[string]interface{}) error {
+	paths, err := c.fs.Glob(filepath.Join(c.SourceDir, sourceDataName+".*"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		ext := filepath.Ext(path)
+		if _, ok := unmarshalFormatMap[strings.ToLower(strings.TrimPrefix(ext, "."))]; !ok {
+			continue
+		}
+		stem := strings.TrimSuffix(filepath.Base(path), ext)
+		if stem != sourceDataName && stem != sourceDataName+".sops" {
+			continue
+		}
+		info, err := c.fs.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		var sourceData map[string]interface{}
+		if stem == sourceDataName+".sops" {
+			var value interface{}
+			if value, err = c.sopsDecrypt(path); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			var ok bool
+			if sourceData, ok = value.(map[string]interface{}); !ok {
+				return fmt.Errorf("%s: not an object", path)
+			}
+			// The decrypted values are secrets, so mask them in output.
+			c.redactor.AddValue(sourceData)
+		} else if sourceData, err = c.readDataFile(path); err != nil {
+			return err
+		}
+		mergeData(data, sourceData)
+	}
+	return nil
+}
+
+// readDataFile returns the data in the file at path. The file's format is
+// determined by its extension.
+func (c *Config) readDataFile(path string) (map[string]interface{}, error) {
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	unmarshal, ok := unmarshalFormatMap[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unknown format", path)
+	}
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	value, err := unmarshal(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: not an object", path)
 	}
 	return data, nil
 }
@@ -411,7 +682,58 @@ func (c *Config) getEntries(ts *chezmoi.TargetState, args []string) ([]chezmoi.E
 	return entries, nil
 }
 
+// A configPersistentState is a persistent state opened by a command. Once it
+// is closed, c no longer uses it.
+type configPersistentState struct {
+	chezmoi.PersistentState
+	c *Config
+}
+
+// Close implements chezmoi.PersistentState.Close.
+func (s *configPersistentState) Close() error {
+	s.c.persistentStateMutex.Lock()
+	if s.c.persistentState == chezmoi.PersistentState(s) {
+		s.c.persistentState = nil
+	}
+	s.c.persistentStateMutex.Unlock()
+	return s.PersistentState.Close()
+}
+
+// getPersistentState opens the persistent state for the current command. It is
+// used by withPersistentState until it is closed.
 func (c *Config) getPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
+	persistentState, err := c.openPersistentState(options)
+	if err != nil {
+		return nil, err
+	}
+	configPersistentState := &configPersistentState{
+		PersistentState: persistentState,
+		c:               c,
+	}
+	c.persistentStateMutex.Lock()
+	c.persistentState = configPersistentState
+	c.persistentStateMutex.Unlock()
+	return configPersistentState, nil
+}
+
+// withPersistentState calls f with the persistent state. If the current
+// command has already opened the persistent state then it is used, otherwise
+// the persistent state is opened only for the duration of f.
+func (c *Config) withPersistentState(f func(chezmoi.PersistentState) error) error {
+	c.persistentStateMutex.Lock()
+	defer c.persistentStateMutex.Unlock()
+	if c.persistentState != nil {
+		return f(c.persistentState)
+	}
+	persistentState, err := c.openPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+	return f(persistentState)
+}
+
+func (c *Config) openPersistentState(options *bolt.Options) (chezmoi.PersistentState, error) {
 	persistentStateFile := c.getPersistentStateFile()
 	if options == nil {
 		options = &bolt.Options{}
@@ -463,6 +785,13 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		c.GPG.Recipient = c.GPGRecipient
 	}
 
+	if c.traceTemplates && c.templateTrace == nil {
+		c.templateTrace = chezmoi.NewTemplateTrace(func(name string) bool {
+			_, ok := c.secretTemplateFuncs[name]
+			return ok
+		})
+	}
+
 	ts := chezmoi.NewTargetState(
 		chezmoi.WithDestDir(destDir),
 		chezmoi.WithGPG(&c.GPG),
@@ -470,8 +799,12 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
+		chezmoi.WithTemplateTrace(c.templateTrace),
 		chezmoi.WithUmask(os.FileMode(c.Umask)),
 	)
+	// Templates executed while populating the target state may already call
+	// includeTemplate, which uses the target state's templates.
+	c.targetState = ts
 	if err := ts.Populate(fs, populateOptions); err != nil {
 		return nil, err
 	}
@@ -645,3 +978,65 @@ func validateKeys(data interface{}, re *regexp.Regexp) error {
 	}
 	return nil
 }
+
+// mergeData merges src into dest recursively. Values in src replace values in
+// dest, except that maps in both src and dest are merged. Maps in src are
+// copied so later merges into dest do not modify src.
+func mergeData(dest, src map[string]interface{}) {
+	for key, srcValue := range src {
+		srcMap, ok := srcValue.(map[string]interface{})
+		if !ok {
+			dest[key] = srcValue
+			continue
+		}
+		destMap, ok := dest[key].(map[string]interface{})
+		if !ok {
+			destMap = make(map[string]interface{}, len(srcMap))
+			dest[key] = destMap
+		}
+		mergeData(destMap, srcMap)
+	}
+}
+
+// setDataValue sets the value at path in data to value, creating maps as
+// needed.
+func setDataValue(data map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		m, ok := data[key].(map[string]interface{})
+		if !ok {
+			m = make(map[string]interface{})
+			data[key] = m
+		}
+		data = m
+	}
+	data[path[len(path)-1]] = value
+}
+
+// normalizeYAMLValue converts the map[interface{}]interface{}s in value, as
+// returned by yaml.Unmarshal, to map[string]interface{}s.
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = normalizeYAMLValue(elem)
+		}
+		return m
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeYAMLValue(elem)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// unmarshalYAML unmarshals data as YAML.
+func unmarshalYAML(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return normalizeYAMLValue(value), nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
 	xdg "github.com/twpayne/go-xdg/v3"
 
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -68,6 +69,112 @@ func TestAutoCommitCommitMessage(t *testing.T) {
 	}
 }
 
+func TestApplyProfile(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/work.yaml": "chezmoi:\n  hostname: work-laptop\n  os: darwin\nemail: john.smith@company.com\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs)
+	c.profile = "/home/user/work.yaml"
+	c.setData = []string{
+		"chezmoi.osRelease.id=ubuntu",
+		"chezmoi.osRelease.versionID=20.10",
+		"work:=true",
+		"ports:=[22, 80]",
+		"git.signingKey=007",
+	}
+	data := map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"hostname": "home",
+			"os":       "linux",
+			"username": "user",
+		},
+		"email": "john@home.org",
+	}
+	require.NoError(t, c.applyProfile(data))
+	assert.Equal(t, map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"hostname": "work-laptop",
+			"os":       "darwin",
+			"osRelease": map[string]interface{}{
+				"id":        "ubuntu",
+				"versionID": "20.10",
+			},
+			"username": "user",
+		},
+		"email": "john.smith@company.com",
+		"git": map[string]interface{}{
+			"signingKey": "007",
+		},
+		"ports": []interface{}{22, 80},
+		"work":  true,
+	}, data)
+
+	for _, setData := range []string{"=value", ":=value", "key:=[value"} {
+		c.setData = []string{setData}
+		assert.Error(t, c.applyProfile(data), setData)
+	}
+}
+
+func TestGetDataSourceData(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoidata.json":     `{"editor":"vi","git":{"email":"john@home.org","name":"John Smith"}}`,
+			".chezmoidata.toml":     "editor = \"vim\"\n",
+			".chezmoidata.yaml":     "ports:\n- 22\n- 80\nproxy:\n  host: proxy.example.com\n",
+			".chezmoidata.yaml~":    "editor: emacs\n",
+			".chezmoidata.yaml.swp": "\x00\x01",
+			".chezmoidata.old.yaml": "editor: nano\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs, withData(map[string]interface{}{
+		"git": map[string]interface{}{
+			"email": "john.smith@company.com",
+		},
+	}))
+	data, err := c.getData()
+	require.NoError(t, err)
+	delete(data, "chezmoi")
+	assert.Equal(t, map[string]interface{}{
+		"editor": "vim",
+		"git": map[string]interface{}{
+			"email": "john.smith@company.com",
+			"name":  "John Smith",
+		},
+		"ports": []interface{}{22, 80},
+		"proxy": map[string]interface{}{
+			"host": "proxy.example.com",
+		},
+	}, data)
+}
+
+func TestWithPersistentState(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0o700},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs)
+
+	persistentState, err := c.getPersistentState(nil)
+	require.NoError(t, err)
+	require.NoError(t, c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		return persistentState.Set([]byte("bucket"), []byte("key"), []byte("value"))
+	}))
+	require.NoError(t, persistentState.Close())
+
+	// Once closed, the persistent state is opened again when needed.
+	var value []byte
+	require.NoError(t, c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		value, err = persistentState.Get([]byte("bucket"), []byte("key"))
+		return err
+	}))
+	assert.Equal(t, []byte("value"), value)
+}
+
 func TestUpperSnakeCaseToCamelCase(t *testing.T) {
 	for s, want := range map[string]string{
 		"BUG_REPORT_URL":   "bugReportURL",
@@ -197,12 +304,24 @@ func withRemoveCmdConfig(remove removeCmdConfig) configOption {
 	}
 }
 
+func withSecretCacheConfig(secretCache secretCacheConfig) configOption {
+	return func(c *Config) {
+		c.SecretCache = secretCache
+	}
+}
+
 func withStdin(stdin io.Reader) configOption {
 	return func(c *Config) {
 		c.Stdin = stdin
 	}
 }
 
+func withStderr(stderr io.Writer) configOption {
+	return func(c *Config) {
+		c.Stderr = stderr
+	}
+}
+
 func withStdout(stdout io.Writer) configOption {
 	return func(c *Config) {
 		c.Stdout = stdout
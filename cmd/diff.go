@@ -38,11 +38,16 @@ func init() {
 	persistentFlags := diffCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.Diff.Format, "format", "f", config.Diff.Format, "format, \"chezmoi\" or \"git\"")
 	persistentFlags.BoolVar(&config.Diff.NoPager, "no-pager", false, "disable pager")
+	addIncludeExcludeFlags(diffCmd)
+	addProfileFlags(diffCmd)
+	addTraceTemplatesFlag(diffCmd)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(diffCmd, 1)
 }
 
 func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
+	defer c.writeTemplateTrace()
+
 	c.DryRun = true // Prevent scripts from running.
 
 	switch c.Diff.Format {
@@ -54,7 +59,7 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("unknown diff format: %q", c.Diff.Format)
 	}
 	if c.Debug {
-		c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		c.mutator = chezmoi.NewDebugMutator(c.mutator, c.getRedactor())
 	}
 
 	persistentState, err := c.getPersistentState(&bolt.Options{
@@ -68,13 +73,13 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	if c.Diff.NoPager || c.Diff.Pager == "" {
 		switch c.Diff.Format {
 		case "chezmoi":
-			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+			c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 		case "git":
 			unifiedEncoder := diff.NewUnifiedEncoder(c.Stdout, diff.DefaultContextLines)
 			if c.colored {
 				unifiedEncoder.SetColor(diff.NewColorConfig())
 			}
-			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+			c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator), c.getRedactor())
 		}
 		return c.applyArgs(args, persistentState)
 	}
@@ -104,13 +109,13 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 
 	switch c.Diff.Format {
 	case "chezmoi":
-		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize)
+		c.mutator = chezmoi.NewVerboseMutator(pagerStdinPipe, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
 	case "git":
 		unifiedEncoder := diff.NewUnifiedEncoder(pagerStdinPipe, diff.DefaultContextLines)
 		if c.colored {
 			unifiedEncoder.SetColor(diff.NewColorConfig())
 		}
-		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator))
+		c.mutator = chezmoi.NewGitDiffMutator(unifiedEncoder, c.mutator, c.DestDir+string(filepath.Separator), c.getRedactor())
 	}
 
 	if err := c.applyArgs(args, persistentState); err != nil {
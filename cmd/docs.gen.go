@@ -1784,7 +1784,10 @@ func init() {
 		"  * [`--follow`](#--follow)\n" +
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
+		"  * [`--log-file` *filename*](#--log-file-filename)\n" +
+		"  * [`--log-format` *format*](#--log-format-format)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
+		"  * [`--show-secrets`](#--show-secrets)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
@@ -1794,6 +1797,8 @@ func init() {
 		"* [Source state attributes](#source-state-attributes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoiattributes`](#chezmoiattributes)\n" +
+		"  * [`.chezmoidata.<format>`](#chezmoidataformat)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
@@ -1802,6 +1807,7 @@ func init() {
 		"  * [`add` *targets*](#add-targets)\n" +
 		"  * [`apply` [*targets*]](#apply-targets)\n" +
 		"  * [`archive`](#archive)\n" +
+		"  * [`backups`](#backups)\n" +
 		"  * [`cat` *targets*](#cat-targets)\n" +
 		"  * [`cd`](#cd)\n" +
 		"  * [`chattr` *attributes* *targets*](#chattr-attributes-targets)\n" +
@@ -1820,9 +1826,11 @@ func init() {
 		"  * [`hg` [*arguments*]](#hg-arguments)\n" +
 		"  * [`init` [*repo*]](#init-repo)\n" +
 		"  * [`import` *filename*](#import-filename)\n" +
+		"  * [`lint`](#lint)\n" +
 		"  * [`manage` *targets*](#manage-targets)\n" +
 		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
+		"  * [`merge-all`](#merge-all)\n" +
 		"  * [`purge`](#purge)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
@@ -1834,14 +1842,24 @@ func init() {
 		"  * [`update`](#update)\n" +
 		"  * [`upgrade`](#upgrade)\n" +
 		"  * [`verify` [*targets*]](#verify-targets)\n" +
+		"  * [`watch`](#watch)\n" +
 		"* [Editor configuration](#editor-configuration)\n" +
 		"* [Umask configuration](#umask-configuration)\n" +
+		"* [Privilege escalation configuration](#privilege-escalation-configuration)\n" +
+		"* [Data profiles](#data-profiles)\n" +
+		"* [Persistent secret cache](#persistent-secret-cache)\n" +
+		"* [Custom secret providers](#custom-secret-providers)\n" +
 		"* [Template execution](#template-execution)\n" +
 		"* [Template variables](#template-variables)\n" +
 		"* [Template functions](#template-functions)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
+		"  * [`fromIni` *text*](#fromini-text)\n" +
+		"  * [`fromJson` *text*](#fromjson-text)\n" +
+		"  * [`fromToml` *text*](#fromtoml-text)\n" +
+		"  * [`fromYaml` *text*](#fromyaml-text)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
 		"  * [`include` *filename*](#include-filename)\n" +
+		"  * [`includeTemplate` *name* *data*](#includetemplate-name-data)\n" +
 		"  * [`joinPath` *elements*](#joinpath-elements)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
@@ -1851,11 +1869,16 @@ func init() {
 		"  * [`onepassword` *uuid* [*vault-uuid*]](#onepassword-uuid-vault-uuid)\n" +
 		"  * [`onepasswordDocument` *uuid* [*vault-uuid*]](#onepassworddocument-uuid-vault-uuid)\n" +
 		"  * [`onepasswordDetailsFields` *uuid* [*vault-uuid*]](#onepassworddetailsfields-uuid-vault-uuid)\n" +
+		"  * [`output` *name* [*args*]](#output-name-args)\n" +
 		"  * [`pass` *pass-name*](#pass-pass-name)\n" +
 		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
+		"  * [`sops` *path*](#sops-path)\n" +
 		"  * [`stat` *name*](#stat-name)\n" +
+		"  * [`toIni` *value*](#toini-value)\n" +
+		"  * [`toToml` *value*](#totoml-value)\n" +
+		"  * [`toYaml` *value*](#toyaml-value)\n" +
 		"  * [`vault` *key*](#vault-key)\n" +
 		"\n" +
 		"## Concepts\n" +
@@ -1921,10 +1944,34 @@ func init() {
 		"\n" +
 		"Print help.\n" +
 		"\n" +
+		"### `--log-file` *filename*\n" +
+		"\n" +
+		"Append a log of every change that chezmoi makes to *filename*. In JSON format,\n" +
+		"each line is a JSON object with the fields `time`, `op`, `path`, `newPath`,\n" +
+		"`oldMode`, `newMode`, `oldSHA256`, `newSHA256`, `oldTarget`, `newTarget`,\n" +
+		"`uid`, `gid`, `command`, `duration` (in seconds), and `error`. Fields that do\n" +
+		"not apply to an operation are omitted. Changes are logged in dry run mode too.\n" +
+		"\n" +
+		"### `--log-format` *format*\n" +
+		"\n" +
+		"Set the format of the log written to `--log-file`. The only supported *format*\n" +
+		"is `json`, which is the default.\n" +
+		"\n" +
 		"### `-r`. `--remove`\n" +
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
 		"\n" +
+		"### `--show-secrets`\n" +
+		"\n" +
+		"Show secrets in output. By default, every string returned by a template\n" +
+		"function that retrieves secrets, for example `bitwarden`, `pass`, or `secret`,\n" +
+		"is replaced with `<redacted>` wherever it appears in verbose output, diffs,\n" +
+		"debug logs, and the output of `dump`. For the items returned by `bitwarden`,\n" +
+		"`keepassxc`, `lastpass`, `onepassword`, and `onepasswordDetailsFields`, the\n" +
+		"fields that describe an item rather than holding its secrets, like its name,\n" +
+		"title, type, and URLs, are not replaced. Strings shorter than four characters are not replaced. Files\n" +
+		"written to the destination directory always contain the secrets.\n" +
+		"\n" +
 		"### `-S`, `--source` *directory*\n" +
 		"\n" +
 		"Use *directory* as the source directory.\n" +
@@ -1955,40 +2002,56 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Section         | Variable     | Type     | Default value             | Description                                         |\n" +
-		"| --------------- | ------------ | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| Top level       | `color`      | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"|                 | `data`       | any      | *none*                    | Template data                                       |\n" +
-		"|                 | `destDir`    | string   | `~`                       | Destination directory                               |\n" +
-		"|                 | `dryRun`     | bool     | `false`                   | Dry run mode                                        |\n" +
-		"|                 | `follow`     | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"|                 | `remove`     | bool     | `false`                   | Remove targets                                      |\n" +
-		"|                 | `sourceDir`  | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"|                 | `umask`      | int      | *from system*             | Umask                                               |\n" +
-		"|                 | `verbose`    | bool     | `false`                   | Verbose mode                                        |\n" +
-		"| `bitwarden`     | `command`    | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd`            | `args`       | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
-		"|                 | `command`    | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `diff`          | `format`     | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
-		"|                 | `pager`      | string   | *none*                    | Pager                                               |\n" +
-		"| `genericSecret` | `command`    | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass`        | `command`    | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg`           | `command`    | string   | `gpg`                     | GPG CLI command                                     |\n" +
-		"|                 | `recipient`  | string   | *none*                    | GPG recipient                                       |\n" +
-		"|                 | `symmetric`  | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc`     | `args`       | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"|                 | `command`    | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"|                 | `database`   | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass`      | `command`    | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge`         | `args`       | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"|                 | `command`    | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword`   | `command`    | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass`          | `command`    | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `sourceVCS`     | `autoCommit` | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"|                 | `autoPush`   | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"|                 | `command`    | string   | `git`                     | Source version control system                       |\n" +
-		"| `template`      | `options`    | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `vault`         | `command`    | string   | `vault`                   | Vault CLI command                                   |\n" +
+		"| Section           | Variable        | Type     | Default value             | Description                                         |\n" +
+		"| ----------------- | --------------- | -------- | ------------------------- | --------------------------------------------------- |\n" +
+		"| Top level         | `color`         | string   | `auto`                    | Colorize diffs                                      |\n" +
+		"|                   | `data`          | any      | *none*                    | Template data                                       |\n" +
+		"|                   | `destDir`       | string   | `~`                       | Destination directory                               |\n" +
+		"|                   | `dryRun`        | bool     | `false`                   | Dry run mode                                        |\n" +
+		"|                   | `follow`        | bool     | `false`                   | Follow symlinks                                     |\n" +
+		"|                   | `parallelism`   | int      | *number of CPUs*          | Maximum number of entries to evaluate concurrently  |\n" +
+		"|                   | `remove`        | bool     | `false`                   | Remove targets                                      |\n" +
+		"|                   | `sourceDir`     | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
+		"|                   | `umask`         | int      | *from system*             | Umask                                               |\n" +
+		"|                   | `verbose`       | bool     | `false`                   | Verbose mode                                        |\n" +
+		"| `autoTemplate`    | `exclude`       | []string | *none*                    | Template variables not used by `--autotemplate`     |\n" +
+		"|                   | `wordBoundary`  | string   | `alphanumeric`            | Word bytes for `--autotemplate`                     |\n" +
+		"| `backup`          | `dir`           | string   | *none*                    | Backup directory                                    |\n" +
+		"| `bitwarden`       | `command`       | string   | `bw`                      | Bitwarden CLI command                               |\n" +
+		"| `cd`              | `args`          | []string | *none*                    | Extra args to shell in `cd` command                 |\n" +
+		"|                   | `command`       | string   | *none*                    | Shell to run in `cd` command                        |\n" +
+		"| `diff`            | `format`        | string   | `chezmoi`                 | Diff format, either `chezmoi` or `git`              |\n" +
+		"|                   | `pager`         | string   | *none*                    | Pager                                               |\n" +
+		"| `escalate`        | `args`          | []string | *none*                    | Extra args to privilege escalation command          |\n" +
+		"|                   | `command`       | string   | `sudo`                    | Privilege escalation command                        |\n" +
+		"|                   | `paths`         | []string | *none*                    | Paths to modify with escalated privileges           |\n" +
+		"| `genericSecret`   | `command`       | string   | *none*                    | Generic secret command                              |\n" +
+		"| `gopass`          | `command`       | string   | `gopass`                  | gopass CLI command                                  |\n" +
+		"| `gpg`             | `command`       | string   | `gpg`                     | GPG CLI command                                     |\n" +
+		"|                   | `recipient`     | string   | *none*                    | GPG recipient                                       |\n" +
+		"|                   | `symmetric`     | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
+		"| `keepassxc`       | `args`          | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
+		"|                   | `command`       | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
+		"|                   | `database`      | string   | *none*                    | KeePassXC database                                  |\n" +
+		"| `lastpass`        | `command`       | string   | `lpass`                   | Lastpass CLI command                                |\n" +
+		"| `merge`           | `args`          | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
+		"|                   | `command`       | string   | `vimdiff`                 | 3-way merge command, or empty for built-in merge    |\n" +
+		"|                   | `recordBase`    | bool     | `false`                   | Record files' contents as the built-in merge's base |\n" +
+		"| `onepassword`     | `command`       | string   | `op`                      | 1Password CLI command                               |\n" +
+		"| `output`          | `timeout`       | duration | `1m`                      | Timeout for commands run by `output`                |\n" +
+		"| `pass`            | `command`       | string   | `pass`                    | Pass CLI command                                    |\n" +
+		"| `secretCache`     | `keyFile`       | string   | *none*                    | Key file to encrypt the persistent secret cache     |\n" +
+		"|                   | `ttl`           | object   | *none*                    | Persistent secret cache time to live by provider    |\n" +
+		"| `secretProviders` | *name*          | object   | *none*                    | [Custom secret providers](#custom-secret-providers) |\n" +
+		"| `secretScan`      | `minEntropy`    | float    | `4.5`                     | Minimum entropy of secrets found by `add`           |\n" +
+		"|                   | `patterns`      | []string | *none*                    | Extra regular expressions matching secrets          |\n" +
+		"| `sops`            | `args`          | []string | *none*                    | Extra args to sops CLI command                      |\n" +
+		"|                   | `command`       | string   | `sops`                    | sops CLI command                                    |\n" +
+		"| `sourceVCS`       | `autoCommit`    | bool     | `false`                   | Commit changes to the source state after any change |\n" +
+		"|                   | `autoPush`      | bool     | `false`                   | Push changes to the source state after any change   |\n" +
+		"|                   | `command`       | string   | `git`                     | Source version control system                       |\n" +
+		"| `template`        | `options`       | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
+		"| `vault`           | `command`       | string   | `vault`                   | Vault CLI command                                   |\n" +
 		"\n" +
 		"### Examples\n" +
 		"\n" +
@@ -2074,6 +2137,66 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoiattributes`\n" +
+		"\n" +
+		"If a file called `.chezmoiattributes` exists in the source state then it is\n" +
+		"interpreted as a list of patterns followed by attributes to set on matching\n" +
+		"targets. Patterns are matched in the same way as `.chezmoiignore`. The\n" +
+		"supported attributes are:\n" +
+		"\n" +
+		"| Attribute | Effect                                           |\n" +
+		"| --------- | ------------------------------------------------ |\n" +
+		"| `owner`   | Set the owner of the target, by name or user ID  |\n" +
+		"| `group`   | Set the group of the target, by name or group ID |\n" +
+		"\n" +
+		"Attributes apply to files and directories only. If multiple patterns match a\n" +
+		"target then later patterns take priority. `apply` changes the ownership of\n" +
+		"targets that differ, and `diff` and `verify` report any differences. Changing\n" +
+		"ownership normally requires chezmoi to run as root, and is not supported on\n" +
+		"Windows.\n" +
+		"\n" +
+		"Comments are introduced with the `#` character and run until the end of the\n" +
+		"line.\n" +
+		"\n" +
+		"`.chezmoiattributes` is interpreted as a template. `.chezmoiattributes` files in\n" +
+		"subdirectories apply only to that subdirectory.\n" +
+		"\n" +
+		"#### `.chezmoiattributes` examples\n" +
+		"\n" +
+		"    etc/**             owner=root group=root\n" +
+		"    etc/sudoers.d/*    group=wheel\n" +
+		"    {{- if eq .chezmoi.os \"darwin\" }}\n" +
+		"    Library/LaunchDaemons/* owner=root group=wheel\n" +
+		"    {{- end }}\n" +
+		"\n" +
+		"### `.chezmoidata.<format>`\n" +
+		"\n" +
+		"If files called `.chezmoidata.<format>` exist in the root of the source state\n" +
+		"then their contents are merged into the template data, in lexical order of their\n" +
+		"filenames. *format* must be one of `json`, `toml`, or `yaml`. Data from the\n" +
+		"config file takes priority over data from `.chezmoidata.<format>` files. Maps\n" +
+		"are merged recursively. Other files whose names start with `.chezmoidata.`, for\n" +
+		"example editor backup files like `.chezmoidata.yaml~`, are ignored.\n" +
+		"\n" +
+		"Files called `.chezmoidata.sops.<format>` are encrypted with\n" +
+		"[sops](https://github.com/mozilla/sops). They are decrypted with `sops --decrypt`\n" +
+		"before being merged. *format* must be a format that sops supports, for example\n" +
+		"`json` or `yaml`. Their values are treated as secrets and masked in output, as\n" +
+		"for [`--show-secrets`](#--show-secrets).\n" +
+		"\n" +
+		"#### `.chezmoidata.<format>` examples\n" +
+		"\n" +
+		"    .chezmoidata.yaml\n" +
+		"    editor: vim\n" +
+		"    git:\n" +
+		"      email: john.smith@company.com\n" +
+		"\n" +
+		"    .chezmoidata.sops.yaml\n" +
+		"    github:\n" +
+		"      token: ENC[AES256_GCM,data:...,type:str]\n" +
+		"    sops:\n" +
+		"      ...\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
@@ -2133,6 +2256,10 @@ func init() {
 		"\n" +
 		"The target state of `.config` will be `bar`.\n" +
 		"\n" +
+		"Templates in `.chezmoitemplates` can also be executed with computed names and\n" +
+		"arbitrary data with the [`includeTemplate`](#includetemplate-name-data) template\n" +
+		"function.\n" +
+		"\n" +
 		"### `.chezmoiversion`\n" +
 		"\n" +
 		"If a file called `.chezmoiversion` exists, then its contents are interpreted as\n" +
@@ -2153,13 +2280,48 @@ func init() {
 		"\n" +
 		"Add *targets* to the source state. If any target is already in the source state,\n" +
 		"then its source state is replaced with its current state in the destination\n" +
-		"directory. The `add` command accepts additional flags:\n" +
+		"directory.\n" +
+		"\n" +
+		"Before adding a file, `chezmoi add` scans its contents for possible secrets,\n" +
+		"such as AWS keys, private keys, GitHub tokens, high-entropy strings, and matches\n" +
+		"for the regular expressions in the `secretScan.patterns` configuration\n" +
+		"variable. If a possible secret is found then the file is not added. Use\n" +
+		"`--encrypt` to add it encrypted, or `--allow-secrets` to add it anyway.\n" +
+		"\n" +
+		"The `add` command accepts additional flags:\n" +
+		"\n" +
+		"#### `--allow-secrets`\n" +
+		"\n" +
+		"Add files even if they may contain secrets.\n" +
 		"\n" +
 		"#### `--autotemplate`\n" +
 		"\n" +
 		"Automatically generate a template by replacing strings with variable names from\n" +
-		"the `data` section of the config file. Longer substitutions occur before shorter\n" +
-		"ones. This implies the `--template` option.\n" +
+		"the template data, including the `data` section of the config file. This\n" +
+		"implies the `--template` option. Files are checked for secrets after the\n" +
+		"strings are replaced.\n" +
+		"\n" +
+		"The file is scanned once for the values of all variables. Where values overlap,\n" +
+		"the value that starts first is replaced, and of values that start at the same\n" +
+		"position, the longest. If several variables have the same value then the first\n" +
+		"by name is used. Any template delimiters in the rest of the file are escaped.\n" +
+		"\n" +
+		"Values are only replaced where they do not start or end in the middle of a\n" +
+		"word. The `autoTemplate.wordBoundary` configuration variable sets which bytes\n" +
+		"are part of words:\n" +
+		"\n" +
+		"| Word boundary  | Word bytes                              |\n" +
+		"| -------------- | --------------------------------------- |\n" +
+		"| `alphanumeric` | ASCII letters and digits                |\n" +
+		"| `identifier`   | ASCII letters, digits, and underscores  |\n" +
+		"| `none`         | None, values are replaced anywhere      |\n" +
+		"\n" +
+		"The values of the variables listed in the `autoTemplate.exclude` configuration\n" +
+		"variable, for example `chezmoi.hostname`, are never replaced. Listing a map, for\n" +
+		"example `chezmoi`, excludes all the variables that it contains.\n" +
+		"\n" +
+		"With `--dry-run`, `chezmoi add --autotemplate` prints each substitution that it\n" +
+		"would make, with its line number, instead of adding the file.\n" +
 		"\n" +
 		"#### `-e`, `--empty`\n" +
 		"\n" +
@@ -2189,6 +2351,7 @@ func init() {
 		"\n" +
 		"    chezmoi add ~/.bashrc\n" +
 		"    chezmoi add ~/.gitconfig --template\n" +
+		"    chezmoi add ~/.gitconfig --autotemplate --dry-run\n" +
 		"    chezmoi add ~/.vim --recursive\n" +
 		"    chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
 		"\n" +
@@ -2197,10 +2360,53 @@ func init() {
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"Templates are executed and encrypted files are decrypted concurrently, using up\n" +
+		"to `parallelism` goroutines, before any changes are made. Secret manager\n" +
+		"commands run one at a time, so that their prompts do not interleave. If any\n" +
+		"target cannot be evaluated then no changes are made and the error for the first\n" +
+		"such target, in the order that targets are applied, is reported.\n" +
+		"\n" +
+		"#### `--interactive`\n" +
+		"\n" +
+		"Before each change, print it and prompt whether to apply it. The choices are:\n" +
+		"\n" +
+		"| Choice | Effect                                                            |\n" +
+		"| ------ | ----------------------------------------------------------------- |\n" +
+		"| `y`    | Apply the change, and any further changes to the same target      |\n" +
+		"| `n`    | Skip the change, and any further changes to the same target       |\n" +
+		"| `a`    | Apply this and all remaining changes without prompting            |\n" +
+		"| `q`    | Skip this and all remaining changes                               |\n" +
+		"| `d`    | Skip this and all remaining changes, but print them               |\n" +
+		"| `m`    | Run the merge command on the target instead of overwriting it     |\n" +
+		"\n" +
+		"Skipping a change to a directory also skips all changes to the targets inside\n" +
+		"it. Removals of targets from `exact_` directories are prompted for separately.\n" +
+		"Scripts are run without prompting.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only apply entries of *types*. *types* is a comma-separated list of types of\n" +
+		"entry to include. Valid types are `dirs`, `files`, `symlinks`, `scripts`,\n" +
+		"`encrypted`, `templates`, and `all`. `dirs`, `files`, and `symlinks` can be\n" +
+		"abbreviated to `d`, `f`, and `s` respectively. `encrypted` matches encrypted\n" +
+		"files and `templates` matches files, symlinks, and scripts that are templates.\n" +
+		"The default is `all`.\n" +
+		"\n" +
+		"Directories that are not included are still created if they contain included\n" +
+		"entries, but their permissions are not changed and, if they are `exact_`,\n" +
+		"extra entries are not removed from them.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Do not apply entries of *types*, even if they are included. *types* is as for\n" +
+		"`--include`.\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
+		"    chezmoi apply --interactive\n" +
+		"    chezmoi apply --exclude=scripts\n" +
 		"    chezmoi apply ~/.bashrc\n" +
 		"\n" +
 		"### `archive`\n" +
@@ -2212,10 +2418,57 @@ func init() {
 		"\n" +
 		"Write the output to *filename* instead of stdout.\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Only include or exclude entries of *types* in the archive, as for\n" +
+		"[`apply`](#apply-targets).\n" +
+		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
 		"#### `archive` examples\n" +
 		"\n" +
 		"    chezmoi archive | tar tvf -\n" +
 		"    chezmoi archive --output=dotfiles.tar\n" +
+		"    chezmoi archive --include=files,symlinks\n" +
+		"    chezmoi archive --profile=work.yaml --output=work.tar\n" +
+		"\n" +
+		"### `backups`\n" +
+		"\n" +
+		"Manage backups of targets. If the `backup.dir` configuration variable is set,\n" +
+		"then every time that chezmoi runs it copies each target to a new subdirectory\n" +
+		"of `backup.dir`, named after the current UTC time, before overwriting or\n" +
+		"removing it. Targets are read without escalated privileges, so targets that\n" +
+		"chezmoi cannot read, for example root-owned files in `escalate.paths`, are not\n" +
+		"backed up and a warning is printed instead. Subdirectories of `backup.dir` with\n" +
+		"other names are not backups and are ignored.\n" +
+		"\n" +
+		"#### `backups list` [*backup*]\n" +
+		"\n" +
+		"List all backups, oldest first. If *backup* is given, list the targets in\n" +
+		"*backup* instead.\n" +
+		"\n" +
+		"#### `backups restore` *backup* [*targets*]\n" +
+		"\n" +
+		"Restore *targets* from *backup*. If no *targets* are specified, all targets in\n" +
+		"*backup* are restored.\n" +
+		"\n" +
+		"#### `backups prune`\n" +
+		"\n" +
+		"Remove all but the most recent backups.\n" +
+		"\n" +
+		"#### `--keep` *n*\n" +
+		"\n" +
+		"Keep the *n* most recent backups when pruning. *n* must not be negative. The\n" +
+		"default is 10.\n" +
+		"\n" +
+		"#### `backups` examples\n" +
+		"\n" +
+		"    chezmoi backups list\n" +
+		"    chezmoi backups list 20200901T093000Z\n" +
+		"    chezmoi backups restore 20200901T093000Z ~/.bashrc\n" +
+		"    chezmoi backups prune --keep=3\n" +
 		"\n" +
 		"### `cat` *targets*\n" +
 		"\n" +
@@ -2223,9 +2476,20 @@ func init() {
 		"symlinks. For files, the target file contents are written. For symlinks, the\n" +
 		"target target is written.\n" +
 		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
+		"#### `--trace-templates`\n" +
+		"\n" +
+		"Print a report of template execution to stderr, as described in [template\n" +
+		"execution](#template-execution).\n" +
+		"\n" +
 		"#### `cat` examples\n" +
 		"\n" +
 		"    chezmoi cat ~/.bashrc\n" +
+		"    chezmoi cat --set chezmoi.os=darwin ~/.bashrc\n" +
+		"    chezmoi cat --trace-templates ~/.gitconfig\n" +
 		"\n" +
 		"### `cd`\n" +
 		"\n" +
@@ -2288,10 +2552,15 @@ func init() {
 		"Print the computed template data in the given format. The accepted formats are\n" +
 		"`json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
 		"#### `data` examples\n" +
 		"\n" +
 		"    chezmoi data\n" +
 		"    chezmoi data --format=yaml\n" +
+		"    chezmoi data --profile=work.yaml\n" +
 		"\n" +
 		"### `diff` [*targets*]\n" +
 		"\n" +
@@ -2321,11 +2590,28 @@ func init() {
 		"\n" +
 		"Do not use the pager.\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Only print differences in entries of the included and not excluded *types*, as\n" +
+		"for [`apply`](#apply-targets).\n" +
+		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
+		"#### `--trace-templates`\n" +
+		"\n" +
+		"Print a report of template execution to stderr, as described in [template\n" +
+		"execution](#template-execution).\n" +
+		"\n" +
 		"#### `diff` examples\n" +
 		"\n" +
 		"    chezmoi diff\n" +
 		"    chezmoi diff ~/.bashrc\n" +
 		"    chezmoi diff --format=git\n" +
+		"    chezmoi diff --include=templates\n" +
+		"    chezmoi diff --profile=work.yaml\n" +
+		"    chezmoi diff --trace-templates ~/.gitconfig\n" +
 		"\n" +
 		"### `docs` [*regexp*]\n" +
 		"\n" +
@@ -2340,7 +2626,8 @@ func init() {
 		"\n" +
 		"### `doctor`\n" +
 		"\n" +
-		"Check for potential problems.\n" +
+		"Check for potential problems, including possible secrets in unencrypted files\n" +
+		"in the source directory.\n" +
 		"\n" +
 		"#### `doctor` examples\n" +
 		"\n" +
@@ -2356,10 +2643,22 @@ func init() {
 		"Print the target state in the given format. The accepted formats are `json`\n" +
 		"(JSON) and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Only dump entries of the included and not excluded *types*, as for\n" +
+		"[`apply`](#apply-targets). Directories containing included entries are always\n" +
+		"dumped.\n" +
+		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
 		"#### `dump` examples\n" +
 		"\n" +
 		"    chezmoi dump ~/.bashrc\n" +
 		"    chezmoi dump --format=yaml\n" +
+		"    chezmoi dump --include=encrypted\n" +
+		"    chezmoi dump --profile=work.yaml\n" +
 		"\n" +
 		"### `edit` [*targets*]\n" +
 		"\n" +
@@ -2416,12 +2715,23 @@ func init() {
 		"`promptString` is called with a *prompt* that does not match any of *pairs*,\n" +
 		"then it returns *prompt* unchanged.\n" +
 		"\n" +
+		"#### `--profile` *filename*, `--set` *key*`=`*value*\n" +
+		"\n" +
+		"Override the template data, as described in [data profiles](#data-profiles).\n" +
+		"\n" +
+		"#### `--trace-templates`\n" +
+		"\n" +
+		"Print a report of template execution to stderr, as described in [template\n" +
+		"execution](#template-execution).\n" +
+		"\n" +
 		"#### `execute-template` examples\n" +
 		"\n" +
 		"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 		"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
+		"    chezmoi execute-template --set chezmoi.hostname=work-laptop '{{ .chezmoi.hostname }}'\n" +
 		"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
 		"    chezmoi execute-template --init --promptString email=john@home.org < ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
+		"    chezmoi execute-template --trace-templates < dot_gitconfig.tmpl\n" +
 		"\n" +
 		"### `forget` *targets*\n" +
 		"\n" +
@@ -2510,6 +2820,45 @@ func init() {
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
 		"\n" +
+		"### `lint`\n" +
+		"\n" +
+		"Check the templates in the source state for problems without executing them.\n" +
+		"All `.tmpl` files, `.chezmoiattributes`, `.chezmoiignore`, and\n" +
+		"`.chezmoiremove` files, and all files in `.chezmoitemplates` are parsed with\n" +
+		"chezmoi's template functions. Syntax errors, references to unknown functions,\n" +
+		"and references to keys that are missing from the template data are reported\n" +
+		"with their file and line. Templates in `.chezmoitemplates` are not checked for\n" +
+		"missing keys, as their data is given when they are used. Encrypted files are\n" +
+		"not checked.\n" +
+		"\n" +
+		"`lint` also scans the files in the source state that are not templates for the\n" +
+		"current machine's `.chezmoi.fullHostname`, `.chezmoi.homedir`,\n" +
+		"`.chezmoi.hostname`, and `.chezmoi.username`, which will be wrong on other\n" +
+		"machines. Values are found as [`add --autotemplate`](#--autotemplate) would\n" +
+		"find them, respecting the `autoTemplate.wordBoundary` and\n" +
+		"`autoTemplate.exclude` configuration variables. For each file that contains one\n" +
+		"of these values, `lint` prints a warning for each line, followed by the\n" +
+		"`chezmoi chattr +template` and `chezmoi add --autotemplate` commands that turn\n" +
+		"the file into a template that uses the variables instead. As these commands\n" +
+		"replace the file in the source state with the file in the destination\n" +
+		"directory, `lint` also warns if the two differ, in which case you should run\n" +
+		"[`merge`](#merge-targets) first. Encrypted files are not checked.\n" +
+		"\n" +
+		"If any problems are found then `lint` exits with a non-zero exit status.\n" +
+		"Warnings about hard-coded values do not change the exit status.\n" +
+		"\n" +
+		"#### `--data` *filename*\n" +
+		"\n" +
+		"Also execute every template with the template data merged with the data in\n" +
+		"*filename*, and report any errors. *filename* must be a JSON, TOML, or YAML file.\n" +
+		"This flag can be given multiple times to check against several machines' data.\n" +
+		"Executing templates may run your secret manager.\n" +
+		"\n" +
+		"#### `lint` examples\n" +
+		"\n" +
+		"    chezmoi lint\n" +
+		"    chezmoi lint --data work.yaml --data home.yaml\n" +
+		"\n" +
 		"### `manage` *targets*\n" +
 		"\n" +
 		"`manage` is an alias for `add` for symmetry with `unmanage`.\n" +
@@ -2521,9 +2870,12 @@ func init() {
 		"#### `-i`, `--include` *types*\n" +
 		"\n" +
 		"Only list entries of type *types*. *types* is a comma-separated list of types of\n" +
-		"entry to include. Valid types are `dirs`, `files`, and `symlinks` which can be\n" +
-		"abbreviated to `d`, `f`, and `s` respectively. By default, `manage` will list\n" +
-		"entries of all types.\n" +
+		"entry to include. Valid types are as for [`apply`](#apply-targets). By default,\n" +
+		"`managed` will list directories, files, and symlinks.\n" +
+		"\n" +
+		"#### `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Do not list entries of type *types*.\n" +
 		"\n" +
 		"#### `managed` examples\n" +
 		"\n" +
@@ -2542,9 +2894,78 @@ func init() {
 		"example if source is a template containing errors or an encrypted file that\n" +
 		"cannot be decrypted) a two-way merge is performed instead.\n" +
 		"\n" +
+		"If `merge.command` is empty, or `--strategy` is given, chezmoi instead uses its\n" +
+		"built-in merge, which does not need a terminal. The built-in merge performs a\n" +
+		"line-based three-way merge, like `diff3`, of the changes to the source state and\n" +
+		"to the target state, and writes the result to the source file. If the\n" +
+		"`merge.recordBase` configuration variable is `true` then chezmoi instead merges\n" +
+		"the changes made since the file was last applied, so that changes to the source\n" +
+		"state and to the destination state can conflict. To do this, chezmoi stores a\n" +
+		"copy of the contents of each file that is not a template or encrypted in its\n" +
+		"persistent state whenever the destination matches the target state after an\n" +
+		"`apply`, and updates it when the file is added or merged. Only enable this if\n" +
+		"you are happy for these files' contents to be stored there. Changes that\n" +
+		"conflict are written to the source file between conflict markers\n" +
+		"and chezmoi exits with an error, unless they are resolved with `--strategy`.\n" +
+		"The built-in merge requires the target state to be computed.\n" +
+		"\n" +
+		"If the source file is a template, the built-in merge instead maps the changes\n" +
+		"from the target state to the destination state back onto the template's literal\n" +
+		"text: changed or deleted lines that come from literal text in the template are\n" +
+		"changed or deleted in the template, and inserted lines are inserted next to the\n" +
+		"literal text that surrounds them, with any template delimiters escaped. Only\n" +
+		"the text in the branches of `if` and `with` actions that ran with the current\n" +
+		"template data is changed. Changes to lines that are generated by template\n" +
+		"actions, or by text inside `range` loops, cannot be merged automatically. chezmoi prints each such change and exits\n" +
+		"with an error, unless `--strategy=ours` is given, in which case they are\n" +
+		"ignored.\n" +
+		"\n" +
+		"#### `--autotemplate`\n" +
+		"\n" +
+		"When merging into a template, replace template data values in the inserted and\n" +
+		"changed text with the template variables that contain them, as for [`add\n" +
+		"--autotemplate`](#--autotemplate).\n" +
+		"\n" +
+		"#### `--strategy` *strategy*\n" +
+		"\n" +
+		"Use the built-in merge and resolve conflicts with *strategy*, which must be one\n" +
+		"of:\n" +
+		"\n" +
+		"| Strategy | Resolution                                                          |\n" +
+		"| -------- | ------------------------------------------------------------------- |\n" +
+		"| `ours`   | Use the source state's changes                                      |\n" +
+		"| `theirs` | Use the destination state's changes                                 |\n" +
+		"| `union`  | Use the source state's changes followed by the destination state's  |\n" +
+		"\n" +
 		"#### `merge` examples\n" +
 		"\n" +
 		"    chezmoi merge ~/.bashrc\n" +
+		"    chezmoi merge --strategy=theirs ~/.bashrc\n" +
+		"    chezmoi merge --autotemplate ~/.gitconfig\n" +
+		"\n" +
+		"### `merge-all`\n" +
+		"\n" +
+		"Perform a three-way merge, as for [`merge`](#merge-targets), for every file\n" +
+		"whose destination state differs from its target state, in turn. Files that\n" +
+		"match their target state, including templates whose output matches, and files\n" +
+		"that do not exist in the destination directory are skipped. chezmoi prints a\n" +
+		"line for each merged file followed by a summary of the number of files merged,\n" +
+		"skipped, and failed, and exits with an error if any file failed to merge.\n" +
+		"\n" +
+		"#### `--autotemplate`\n" +
+		"\n" +
+		"When merging into templates, replace template data values with template\n" +
+		"variables, as for [`merge`](#merge-targets).\n" +
+		"\n" +
+		"#### `--strategy` *strategy*\n" +
+		"\n" +
+		"Use the built-in merge and resolve conflicts with *strategy*, as for\n" +
+		"[`merge`](#merge-targets).\n" +
+		"\n" +
+		"#### `merge-all` examples\n" +
+		"\n" +
+		"    chezmoi merge-all\n" +
+		"    chezmoi merge-all --strategy=ours\n" +
 		"\n" +
 		"### `purge`\n" +
 		"\n" +
@@ -2584,14 +3005,19 @@ func init() {
 		"\n" +
 		"    chezmoi secret help\n" +
 		"\n" +
+		"`chezmoi secret cache clear` removes all secrets from the [persistent secret\n" +
+		"cache](#persistent-secret-cache).\n" +
+		"\n" +
 		"#### `secret` examples\n" +
 		"\n" +
 		"    chezmoi secret bitwarden list items\n" +
+		"    chezmoi secret cache clear\n" +
 		"    chezmoi secret lastpass ls\n" +
 		"    chezmoi secret lastpass -- show --format=json id\n" +
 		"    chezmoi secret onepassword list items\n" +
 		"    chezmoi secret onepassword get item id\n" +
 		"    chezmoi secret pass show id\n" +
+		"    chezmoi secret sops -- --decrypt secrets.sops.yaml\n" +
 		"    chezmoi secret vault -- kv get -format=json id\n" +
 		"\n" +
 		"### `source` [*args*]\n" +
@@ -2664,11 +3090,74 @@ func init() {
 		"(success) if all targets match their target state, or 1 (failure) otherwise. If\n" +
 		"no targets are specified then all targets are checked.\n" +
 		"\n" +
+		"#### `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Only verify entries of the included and not excluded *types*, as for\n" +
+		"[`apply`](#apply-targets).\n" +
+		"\n" +
 		"#### `verify` examples\n" +
 		"\n" +
 		"    chezmoi verify\n" +
 		"    chezmoi verify ~/.bashrc\n" +
 		"\n" +
+		"### `watch`\n" +
+		"\n" +
+		"Watch the source directory and, whenever it changes, apply the targets affected\n" +
+		"by the change. Changes to a source file or directory apply only the\n" +
+		"corresponding target. Changes to special files and directories like\n" +
+		"`.chezmoiignore`, `.chezmoidata.<format>`, and `.chezmoitemplates` apply all\n" +
+		"targets. chezmoi prints a line for each target that it updates and continues\n" +
+		"watching if the target state cannot be computed, for example because of an error\n" +
+		"in a template. Press Ctrl-C to stop watching.\n" +
+		"\n" +
+		"#### `--add`\n" +
+		"\n" +
+		"With `--targets`, add each changed target to the source state, as for\n" +
+		"[`add`](#add-targets), keeping its source attributes. Targets whose source is a\n" +
+		"template are not added. As with `add`, files that may contain secrets are not\n" +
+		"added unless they are encrypted or `--allow-secrets` is given.\n" +
+		"\n" +
+		"#### `--allow-secrets`\n" +
+		"\n" +
+		"With `--add`, add files that may contain secrets without encrypting them.\n" +
+		"\n" +
+		"#### `--debounce` *duration*\n" +
+		"\n" +
+		"Wait until no further changes have been made for *duration* before applying\n" +
+		"them. The default is `100ms`.\n" +
+		"\n" +
+		"#### `--encrypt`\n" +
+		"\n" +
+		"With `--add`, encrypt each added file, as for `add --encrypt`.\n" +
+		"\n" +
+		"#### `--merge`\n" +
+		"\n" +
+		"With `--targets`, run [`merge`](#merge-targets) on each changed file that is not\n" +
+		"added by `--add`.\n" +
+		"\n" +
+		"#### `--targets`\n" +
+		"\n" +
+		"Watch the managed files and symlinks in the destination directory instead of the\n" +
+		"source directory. When a target is changed outside chezmoi so that it no longer\n" +
+		"matches its target state, chezmoi prints the difference between the target and\n" +
+		"its target state.\n" +
+		"\n" +
+		"#### `--watch-config`\n" +
+		"\n" +
+		"Also watch the config file. When it changes, chezmoi reads it again and applies\n" +
+		"all targets.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+		"\n" +
+		"Only apply entries of the included and not excluded *types*, as for\n" +
+		"[`apply`](#apply-targets).\n" +
+		"\n" +
+		"#### `watch` examples\n" +
+		"\n" +
+		"    chezmoi watch\n" +
+		"    chezmoi watch --watch-config --verbose\n" +
+		"    chezmoi watch --targets --add\n" +
+		"\n" +
 		"## Editor configuration\n" +
 		"\n" +
 		"The `edit` and `edit-config` commands use the editor specified by the `VISUAL`\n" +
@@ -2687,6 +3176,126 @@ func init() {
 		"\n" +
 		"    umask = 0o22\n" +
 		"\n" +
+		"## Privilege escalation configuration\n" +
+		"\n" +
+		"chezmoi normally modifies targets with your own privileges. To manage targets\n" +
+		"that you cannot modify directly, for example in `/etc`, list their paths in the\n" +
+		"`escalate.paths` configuration variable. Changes to these paths, and anything\n" +
+		"below them, are made by running `chmod`, `chown`, `install`, `ln`, `mkdir`,\n" +
+		"`mv`, and `rm` through the command set in `escalate.command`, for example:\n" +
+		"\n" +
+		"    [escalate]\n" +
+		"      command = \"doas\"\n" +
+		"      paths = [\"/etc\"]\n" +
+		"\n" +
+		"With `--dry-run` or `--verbose`, chezmoi prints the escalated commands instead\n" +
+		"of, or as well as, running them.\n" +
+		"\n" +
+		"Only changes are escalated. chezmoi still reads the current state of targets\n" +
+		"with your own privileges, so targets in `escalate.paths` must be readable by\n" +
+		"you, and chezmoi reports an error for targets that are not. For example, a\n" +
+		"target like `/etc/sudoers` with permissions `0440` and owned by root cannot be\n" +
+		"managed.\n" +
+		"\n" +
+		"## Data profiles\n" +
+		"\n" +
+		"The `archive`, `cat`, `data`, `diff`, `dump`, and `execute-template` commands\n" +
+		"can render templates as if they were run on a different machine by overriding\n" +
+		"the template data, including the automatically populated `.chezmoi` variables.\n" +
+		"This allows you to check the target state for all of your machines on one\n" +
+		"machine, for example in CI.\n" +
+		"\n" +
+		"`--profile` *filename* merges the data in *filename*, which must be a JSON,\n" +
+		"TOML, or YAML file, into the template data. Maps are merged recursively.\n" +
+		"\n" +
+		"`--set` *key*`=`*value* sets a single value, after any profile is applied.\n" +
+		"*key* is a path of keys separated by dots and *value* is always a string, so\n" +
+		"`--set chezmoi.osRelease.versionID=20.10` sets the version to `20.10`. To set a\n" +
+		"value of another type, use `--set` *key*`:=`*value*, in which case *value* is\n" +
+		"parsed as YAML, for example `--set work:=true` or `--set 'ports:=[22, 80]'`.\n" +
+		"`--set` can be given multiple times.\n" +
+		"\n" +
+		"For example, with `work.yaml`:\n" +
+		"\n" +
+		"    chezmoi:\n" +
+		"      hostname: work-laptop\n" +
+		"      os: darwin\n" +
+		"      osRelease: {}\n" +
+		"    email: john.smith@company.com\n" +
+		"\n" +
+		"run:\n" +
+		"\n" +
+		"    chezmoi dump --profile=work.yaml\n" +
+		"    chezmoi archive --profile=work.yaml --set chezmoi.os=linux | tar tvf -\n" +
+		"\n" +
+		"Only the template data changes. The destination directory and the behavior of\n" +
+		"template functions, such as `lookPath` and `output`, are not affected.\n" +
+		"\n" +
+		"## Persistent secret cache\n" +
+		"\n" +
+		"Within a single invocation, chezmoi runs each secret manager command at most\n" +
+		"once. To also reuse secrets across invocations, set a time to live for each\n" +
+		"secret manager in the `secretCache.ttl` configuration variable. The output of\n" +
+		"that secret manager's commands is then stored in the persistent state and reused\n" +
+		"until it is older than the time to live, for example:\n" +
+		"\n" +
+		"    [secretCache.ttl]\n" +
+		"      lastpass = \"8h\"\n" +
+		"      onepassword = \"1h\"\n" +
+		"\n" +
+		"The keys are `bitwarden`, `genericSecret`, `gopass`, `keepassxc`, `lastpass`,\n" +
+		"`onepassword`, `pass`, `sops`, and `vault`. Secret managers without a time to live are\n" +
+		"not cached.\n" +
+		"\n" +
+		"Cached secrets are encrypted. If `secretCache.keyFile` is set then they are\n" +
+		"encrypted with AES-GCM using a key derived from the contents of that file.\n" +
+		"Otherwise they are encrypted with GPG using the `gpg` configuration. Keys stored\n" +
+		"in the OS keyring are not supported.\n" +
+		"\n" +
+		"With `--dry-run`, cached secrets are used but new secrets are not added to the\n" +
+		"cache.\n" +
+		"\n" +
+		"To remove all cached secrets, run:\n" +
+		"\n" +
+		"    chezmoi secret cache clear\n" +
+		"\n" +
+		"## Custom secret providers\n" +
+		"\n" +
+		"Secret managers that chezmoi does not support directly can be declared in the\n" +
+		"`secretProviders` configuration variable. Each provider adds a template function\n" +
+		"with the provider's name. Configuration keys are case insensitive, so use a\n" +
+		"lowercase name. Each provider has the following variables:\n" +
+		"\n" +
+		"| Variable   | Type     | Description                                                   |\n" +
+		"| ---------- | -------- | ------------------------------------------------------------- |\n" +
+		"| `command`  | string   | Command to run                                                |\n" +
+		"| `args`     | []string | Args templates, by default the template function's arguments  |\n" +
+		"| `format`   | string   | Output format, either `raw` (default), `json`, or `keyValue`  |\n" +
+		"| `jsonPath` | string   | Path to the value to return, for `json` and `keyValue` output |\n" +
+		"\n" +
+		"Each element of `args` is a template that is executed with the template\n" +
+		"function's arguments as `.args` and gives exactly one argument to `command`.\n" +
+		"`raw` output is returned as a string with leading and trailing whitespace\n" +
+		"removed. `json` output is parsed as JSON. `keyValue` output is parsed as lines\n" +
+		"of `key: value` or `key=value` pairs. `jsonPath` is a dot-separated list of\n" +
+		"object keys and array indexes, for example `data.items.0.value`.\n" +
+		"\n" +
+		"For example, to retrieve passwords from [`rbw`](https://github.com/doy/rbw):\n" +
+		"\n" +
+		"    [secretProviders.rbw]\n" +
+		"      command = \"rbw\"\n" +
+		"      args = [\"get\", \"--raw\", \"{{ index .args 0 }}\"]\n" +
+		"      format = \"json\"\n" +
+		"      jsonPath = \"data.password\"\n" +
+		"\n" +
+		"and then use it in a template:\n" +
+		"\n" +
+		"    password = {{ rbw \"example.com\" }}\n" +
+		"\n" +
+		"Like the built-in secret managers, the output of each command is cached and can\n" +
+		"be stored in the [persistent secret cache](#persistent-secret-cache) using the\n" +
+		"provider's name.\n" +
+		"\n" +
 		"## Template execution\n" +
 		"\n" +
 		"chezmoi executes templates using\n" +
@@ -2714,6 +3323,14 @@ func init() {
 		"For a full list of options, see\n" +
 		"[`Template.Option`](https://pkg.go.dev/text/template?tab=doc#Template.Option).\n" +
 		"\n" +
+		"The `cat`, `diff`, and `execute-template` commands accept a `--trace-templates`\n" +
+		"flag, which prints a report of each template's execution to stderr when the\n" +
+		"command finishes. For each template, the report lists, in order of execution,\n" +
+		"the data keys used by each action, the branches of `if`, `range`, and `with`\n" +
+		"actions that ran, the templates included with `template`, and every function\n" +
+		"call with its arguments and result. The results of secret manager functions are\n" +
+		"replaced with `<redacted>`.\n" +
+		"\n" +
 		"## Template variables\n" +
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
@@ -2731,7 +3348,9 @@ func init() {
 		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
 		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
 		"\n" +
-		"Additional variables can be defined in the config file in the `data` section.\n" +
+		"Additional variables can be defined in the config file in the `data` section\n" +
+		"and in [`.chezmoidata.<format>`](#chezmoidataformat) files in the source\n" +
+		"directory.\n" +
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
 		"and/or digits.\n" +
 		"\n" +
@@ -2755,6 +3374,43 @@ func init() {
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"### `fromIni` *text*\n" +
+		"\n" +
+		"`fromIni` returns the parsed value of *text* in INI format. Keys that are not in\n" +
+		"a section are returned as top level keys, and each section is returned as a map\n" +
+		"of its keys to their values, which are strings.\n" +
+		"\n" +
+		"#### `fromIni` examples\n" +
+		"\n" +
+		"    {{ (fromIni (include \"dot_gitconfig\")).user.name }}\n" +
+		"\n" +
+		"### `fromJson` *text*\n" +
+		"\n" +
+		"`fromJson` returns the parsed value of *text* in JSON format. Use sprig's\n" +
+		"`toJson` and `toPrettyJson` functions to convert values to JSON.\n" +
+		"\n" +
+		"#### `fromJson` examples\n" +
+		"\n" +
+		"    {{ (fromJson (include \"settings.json\")).editor }}\n" +
+		"\n" +
+		"### `fromToml` *text*\n" +
+		"\n" +
+		"`fromToml` returns the parsed value of *text* in TOML format.\n" +
+		"\n" +
+		"#### `fromToml` examples\n" +
+		"\n" +
+		"    {{ (fromToml \"[core]\\neditor = \\\"vim\\\"\").core.editor }}\n" +
+		"\n" +
+		"### `fromYaml` *text*\n" +
+		"\n" +
+		"`fromYaml` returns the parsed value of *text* in YAML format.\n" +
+		"\n" +
+		"#### `fromYaml` examples\n" +
+		"\n" +
+		"    {{ range (fromYaml (include \"hosts.yaml\")).hosts }}\n" +
+		"    {{ . }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `gopass` *gopass-name*\n" +
 		"\n" +
 		"`gopass` returns passwords stored in [gopass](https://www.gopass.pw/) using the\n" +
@@ -2772,6 +3428,20 @@ func init() {
 		"`include` returns the literal contents of the file named `*filename*`, relative\n" +
 		"to the source directory.\n" +
 		"\n" +
+		"### `includeTemplate` *name* *data*\n" +
+		"\n" +
+		"`includeTemplate` returns the result of executing the template *name* with\n" +
+		"*data*. *name* is first looked up in the\n" +
+		"[`.chezmoitemplates`](#chezmoitemplates) directory and then as a file relative\n" +
+		"to the source directory. Unlike the `template` action, *name* can be computed\n" +
+		"and the result can be used in pipelines. All templates in the\n" +
+		"`.chezmoitemplates` directory are available to the template.\n" +
+		"\n" +
+		"#### `includeTemplate` examples\n" +
+		"\n" +
+		"    {{ includeTemplate \"gitconfig-user\" .work | indent 4 }}\n" +
+		"    {{ includeTemplate (printf \"hosts/%s.tmpl\" .chezmoi.hostname) . }}\n" +
+		"\n" +
 		"### `joinPath` *elements*\n" +
 		"\n" +
 		"`joinPath` joins any number of path elements into a single path, separating them\n" +
@@ -2948,6 +3618,20 @@ func init() {
 		"\n" +
 		"    {{ (onepasswordDetailsFields \"<uuid>\").password.value }}\n" +
 		"\n" +
+		"### `output` *name* [*args*]\n" +
+		"\n" +
+		"`output` returns the output of running the command *name* with *args*. If the\n" +
+		"command exits with an error then template execution fails and the error\n" +
+		"includes the command's standard error. If the command runs for longer than the\n" +
+		"`output.timeout` configuration variable then it is killed and template execution\n" +
+		"fails. The output is cached so calling `output` multiple times with the same\n" +
+		"*name* and *args* will only run the command once.\n" +
+		"\n" +
+		"#### `output` examples\n" +
+		"\n" +
+		"    {{ output \"brew\" \"--prefix\" | trim }}\n" +
+		"    {{ output \"go\" \"env\" \"GOPATH\" | trim }}\n" +
+		"\n" +
 		"### `pass` *pass-name*\n" +
 		"\n" +
 		"`pass` returns passwords stored in [pass](https://www.passwordstore.org/) using\n" +
@@ -3004,6 +3688,46 @@ func init() {
 		"    # ~/.pyenv exists\n" +
 		"    {{ end }}\n" +
 		"\n" +
+		"### `sops` *path*\n" +
+		"\n" +
+		"`sops` returns the structured data in the file at *path*, which is encrypted\n" +
+		"with [sops](https://github.com/mozilla/sops), using the sops CLI (`sops`).\n" +
+		"Relative paths are relative to the source directory. The file is decrypted with\n" +
+		"`sops --decrypt --output-type json <path>`, preceded by any extra args in the\n" +
+		"`sops.args` configuration variable, and the output is parsed as JSON. The output\n" +
+		"from `sops` is cached so calling `sops` multiple times with the same *path* will\n" +
+		"only invoke `sops` once.\n" +
+		"\n" +
+		"#### `sops` examples\n" +
+		"\n" +
+		"    {{ (sops \"secrets.sops.yaml\").github.token }}\n" +
+		"\n" +
+		"### `toIni` *value*\n" +
+		"\n" +
+		"`toIni` returns *value*, which must be a map, in INI format. Keys whose values\n" +
+		"are maps become sections, and nested maps become sections with names joined by\n" +
+		"`.`. All other values become keys in the enclosing section.\n" +
+		"\n" +
+		"#### `toIni` examples\n" +
+		"\n" +
+		"    {{ toIni .gitconfig }}\n" +
+		"\n" +
+		"### `toToml` *value*\n" +
+		"\n" +
+		"`toToml` returns *value*, which must be a map, in TOML format.\n" +
+		"\n" +
+		"#### `toToml` examples\n" +
+		"\n" +
+		"    {{ toToml .starship }}\n" +
+		"\n" +
+		"### `toYaml` *value*\n" +
+		"\n" +
+		"`toYaml` returns *value* in YAML format.\n" +
+		"\n" +
+		"#### `toYaml` examples\n" +
+		"\n" +
+		"    {{ toYaml (merge .common .work) }}\n" +
+		"\n" +
 		"### `vault` *key*\n" +
 		"\n" +
 		"`vault` returns structured data from [Vault](https://www.vaultproject.io/) using\n" +
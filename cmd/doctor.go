@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +13,8 @@ import (
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
 	shell "github.com/twpayne/go-shell"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var doctorCmd = &cobra.Command{
@@ -72,6 +75,12 @@ type doctorFileCheck struct {
 
 type doctorRuntimeCheck struct{}
 
+type doctorSecretsCheck struct {
+	path          string
+	secretScanner *chezmoi.SecretScanner
+	found         []string
+}
+
 type doctorSuspiciousFilesCheck struct {
 	path      string
 	filenames map[string]bool
@@ -116,6 +125,11 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		mustSucceed: true,
 	}
 
+	secretScanner, err := c.getSecretScanner()
+	if err != nil {
+		return err
+	}
+
 	allOK := true
 	for _, dc := range []doctorCheck{
 		&doctorVersionCheck{},
@@ -131,6 +145,10 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 				".chezmoignore": true,
 			},
 		},
+		&doctorSecretsCheck{
+			path:          c.SourceDir,
+			secretScanner: secretScanner,
+		},
 		&doctorDirectoryCheck{
 			name: "destination directory",
 			path: c.DestDir,
@@ -193,6 +211,12 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			versionArgs:   []string{"version"},
 			versionRegexp: regexp.MustCompile(`(?m)=\s*v(\d+\.\d+\.\d+)`),
 		},
+		&doctorBinaryCheck{
+			name:          "sops CLI",
+			binaryName:    c.Sops.Command,
+			versionArgs:   []string{"--version"},
+			versionRegexp: regexp.MustCompile(`^sops\s+(\d+\.\d+\.\d+)`),
+		},
 		&doctorBinaryCheck{
 			name:          "Vault CLI",
 			binaryName:    c.Vault.Command,
@@ -395,6 +419,58 @@ func (doctorRuntimeCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorSecretsCheck) Check() (bool, error) {
+	if _, err := os.Stat(c.path); os.IsNotExist(err) {
+		return true, nil
+	}
+	if err := filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(info.Name(), ".") && path != c.path && info.Name() != ".chezmoitemplates" {
+			// Skip the VCS directory and other special files and
+			// directories, except for templates.
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || chezmoi.ParseFileAttributes(info.Name()).Encrypted {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, finding := range c.secretScanner.Scan(data) {
+			c.found = append(c.found, fmt.Sprintf("%s:%d: %s", path, finding.Line, finding.Rule))
+		}
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return len(c.found) == 0, nil
+}
+
+func (c *doctorSecretsCheck) Enabled() bool {
+	return true
+}
+
+func (c *doctorSecretsCheck) MustSucceed() bool {
+	return false
+}
+
+func (c *doctorSecretsCheck) Result() string {
+	if len(c.found) == 0 {
+		return "no possible secrets in unencrypted source files"
+	}
+	return fmt.Sprintf("%s (possible secrets, consider encrypting these files)", strings.Join(c.found, ", "))
+}
+
+func (c *doctorSecretsCheck) Skip() bool {
+	return false
+}
+
 func (c *doctorSuspiciousFilesCheck) Check() (bool, error) {
 	if err := filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
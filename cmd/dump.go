@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type dumpCmdConfig struct {
@@ -28,6 +30,8 @@ func init() {
 	persistentFlags := dumpCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.dump.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
 	persistentFlags.BoolVarP(&config.dump.recursive, "recursive", "r", true, "recursive")
+	addIncludeExcludeFlags(dumpCmd)
+	addProfileFlags(dumpCmd)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(dumpCmd, 1)
 }
@@ -37,13 +41,17 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.dump.format)
 	}
+	include, err := chezmoi.NewIncludeSet(c.include, c.exclude)
+	if err != nil {
+		return err
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
 	var concreteValue interface{}
 	if len(args) == 0 {
-		concreteValue, err = ts.ConcreteValue(c.dump.recursive)
+		concreteValue, err = ts.ConcreteValue(include, c.dump.recursive)
 		if err != nil {
 			return err
 		}
@@ -54,7 +62,7 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 		}
 		var concreteValues []interface{}
 		for _, entry := range entries {
-			entryConcreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, os.FileMode(c.Umask), c.dump.recursive)
+			entryConcreteValue, err := entry.ConcreteValue(ts.TargetIgnore.Match, include, ts.SourceDir, os.FileMode(c.Umask), c.dump.recursive)
 			if err != nil {
 				return err
 			}
@@ -64,5 +72,5 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 		}
 		concreteValue = concreteValues
 	}
-	return format(c.Stdout, concreteValue)
+	return format(c.Stdout, c.getRedactor().RedactValue(concreteValue))
 }
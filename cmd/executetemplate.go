@@ -30,9 +30,13 @@ func init() {
 	persistentFlags.BoolVarP(&config.executeTemplate.init, "init", "i", false, "simulate chezmoi init")
 	persistentFlags.StringVarP(&config.executeTemplate.output, "output", "o", "", "output filename")
 	persistentFlags.StringToStringVarP(&config.executeTemplate.promptString, "promptString", "p", nil, "simulate promptString")
+	addProfileFlags(executeTemplateCmd)
+	addTraceTemplatesFlag(executeTemplateCmd)
 }
 
 func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error {
+	defer c.writeTemplateTrace()
+
 	if c.executeTemplate.init {
 		c.templateFuncs["promptString"] = func(prompt string) string {
 			if value, ok := c.executeTemplate.promptString[prompt]; ok {
@@ -13,13 +13,53 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Add *targets* to the source state. If any target is already in the source\n" +
 			"  state, then its source state is replaced with its current state in the\n" +
-			"  destination directory. The `add` command accepts additional flags:\n" +
+			"  destination directory.\n" +
+			"\n" +
+			"  Before adding a file, `chezmoi add` scans its contents for possible secrets,\n" +
+			"  such as AWS keys, private keys, GitHub tokens, high-entropy strings, and\n" +
+			"  matches for the regular expressions in the `secretScan.patterns`\n" +
+			"  configuration variable. If a possible secret is found then the file is not\n" +
+			"  added. Use `--encrypt` to add it encrypted, or `--allow-secrets` to add it\n" +
+			"  anyway.\n" +
+			"\n" +
+			"  The `add` command accepts additional flags:\n" +
+			"\n" +
+			"  `--allow-secrets`\n" +
+			"\n" +
+			"  Add files even if they may contain secrets.\n" +
 			"\n" +
 			"  `--autotemplate`\n" +
 			"\n" +
 			"  Automatically generate a template by replacing strings with variable names\n" +
-			"  from the `data` section of the config file. Longer substitutions occur\n" +
-			"  before shorter ones. This implies the `--template` option.\n" +
+			"  from the template data, including the `data` section of the config file.\n" +
+			"  This implies the `--template` option. Files are checked for secrets after the\n" +
+			"  strings are replaced.\n" +
+			"\n" +
+			"  The file is scanned once for the values of all variables. Where values\n" +
+			"  overlap, the value that starts first is replaced, and of values that start\n" +
+			"  at the same position, the longest. If several variables have the same value\n" +
+			"  then the first by name is used. Any template delimiters in the rest of the\n" +
+			"  file are escaped.\n" +
+			"\n" +
+			"  Values are only replaced where they do not start or end in the middle of a\n" +
+			"  word. The `autoTemplate.wordBoundary` configuration variable sets which\n" +
+			"  bytes are part of words:\n" +
+			"\n" +
+			"    WORD BOUNDARY |           WORD BYTES\n" +
+			"  ----------------+---------------------------------\n" +
+			"    alphanumeric  | ASCII letters and digits\n" +
+			"    identifier    | ASCII letters, digits, and\n" +
+			"                  | underscores\n" +
+			"    none          | None, values are replaced\n" +
+			"                  | anywhere\n" +
+			"\n" +
+			"  The values of the variables listed in the `autoTemplate.exclude`\n" +
+			"  configuration variable, for example `chezmoi.hostname`, are never replaced.\n" +
+			"  Listing a map, for example `chezmoi`, excludes all the variables that it\n" +
+			"  contains.\n" +
+			"\n" +
+			"  With `--dry-run`, `chezmoi add --autotemplate` prints each substitution that it\n" +
+			"  would make, with its line number, instead of adding the file.\n" +
 			"\n" +
 			"  `-e`, `--empty`\n" +
 			"\n" +
@@ -48,6 +88,7 @@ var helps = map[string]help{
 		example: "" +
 			"    chezmoi add ~/.bashrc\n" +
 			"    chezmoi add ~/.gitconfig --template\n" +
+			"    chezmoi add ~/.gitconfig --autotemplate --dry-run\n" +
 			"    chezmoi add ~/.vim --recursive\n" +
 			"    chezmoi add ~/.oh-my-zsh --exact --recursive",
 	},
@@ -55,10 +96,63 @@ var helps = map[string]help{
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary.\n" +
-			"  If no targets are specified, the state of all targets are ensured.",
+			"  If no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  Templates are executed and encrypted files are decrypted concurrently, using\n" +
+			"  up to `parallelism` goroutines, before any changes are made. Secret manager\n" +
+			"  commands run one at a time, so that their prompts do not interleave. If any\n" +
+			"  target cannot be evaluated then no changes are made and the error for the\n" +
+			"  first such target, in the order that targets are applied, is reported.\n" +
+			"\n" +
+			"  `--interactive`\n" +
+			"\n" +
+			"  Before each change, print it and prompt whether to apply it. The choices\n" +
+			"  are:\n" +
+			"\n" +
+			"    CHOICE |             EFFECT\n" +
+			"  ---------+---------------------------------\n" +
+			"    y      | Apply the change, and any\n" +
+			"           | further changes to the same\n" +
+			"           | target\n" +
+			"    n      | Skip the change, and any\n" +
+			"           | further changes to the same\n" +
+			"           | target\n" +
+			"    a      | Apply this and all remaining\n" +
+			"           | changes without prompting\n" +
+			"    q      | Skip this and all remaining\n" +
+			"           | changes\n" +
+			"    d      | Skip this and all remaining\n" +
+			"           | changes, but print them\n" +
+			"    m      | Run the merge command on the\n" +
+			"           | target instead of overwriting\n" +
+			"           | it\n" +
+			"\n" +
+			"  Skipping a change to a directory also skips all changes to the targets\n" +
+			"  inside it. Removals of targets from `exact_` directories are prompted for\n" +
+			"  separately. Scripts are run without prompting.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only apply entries of *types*. *types* is a comma-separated list of types of\n" +
+			"  entry to include. Valid types are `dirs`, `files`, `symlinks`, `scripts`,\n" +
+			"  `encrypted`, `templates`, and `all`. `dirs`, `files`, and `symlinks` can be\n" +
+			"  abbreviated to `d`, `f`, and `s` respectively. `encrypted` matches encrypted\n" +
+			"  files and `templates` matches files, symlinks, and scripts that are\n" +
+			"  templates. The default is `all`.\n" +
+			"\n" +
+			"  Directories that are not included are still created if they contain included\n" +
+			"  entries, but their permissions are not changed and, if they are `exact_`,\n" +
+			"  extra entries are not removed from them.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Do not apply entries of *types*, even if they are included. *types* is as\n" +
+			"  for `--include`.",
 		example: "" +
 			"    chezmoi apply\n" +
 			"    chezmoi apply --dry-run --verbose\n" +
+			"    chezmoi apply --interactive\n" +
+			"    chezmoi apply --exclude=scripts\n" +
 			"    chezmoi apply ~/.bashrc",
 	},
 	"archive": {
@@ -69,19 +163,76 @@ var helps = map[string]help{
 			"\n" +
 			"  `--output`, `-o` *filename*\n" +
 			"\n" +
-			"  Write the output to *filename* instead of stdout.",
+			"  Write the output to *filename* instead of stdout.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Only include or exclude entries of *types* in the archive, as for apply.\n" +
+			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.",
 		example: "" +
 			"    chezmoi archive | tar tvf -\n" +
-			"    chezmoi archive --output=dotfiles.tar",
+			"    chezmoi archive --output=dotfiles.tar\n" +
+			"    chezmoi archive --include=files,symlinks\n" +
+			"    chezmoi archive --profile=work.yaml --output=work.tar",
+	},
+	"backups": {
+		long: "" +
+			"Description:\n" +
+			"  Manage backups of targets. If the `backup.dir` configuration variable is\n" +
+			"  set, then every time that chezmoi runs it copies each target to a new\n" +
+			"  subdirectory of `backup.dir`, named after the current UTC time, before\n" +
+			"  overwriting or removing it. Targets are read without escalated privileges,\n" +
+			"  so targets that chezmoi cannot read, for example root-owned files in\n" +
+			"  `escalate.paths`, are not backed up and a warning is printed instead.\n" +
+			"  Subdirectories of `backup.dir` with other names are not backups and are\n" +
+			"  ignored.\n" +
+			"\n" +
+			"  `backups list` [*backup*]\n" +
+			"\n" +
+			"  List all backups, oldest first. If *backup* is given, list the targets in\n" +
+			"  *backup* instead.\n" +
+			"\n" +
+			"  `backups restore` *backup* [*targets*]\n" +
+			"\n" +
+			"  Restore *targets* from *backup*. If no *targets* are specified, all targets\n" +
+			"  in *backup* are restored.\n" +
+			"\n" +
+			"  `backups prune`\n" +
+			"\n" +
+			"  Remove all but the most recent backups.\n" +
+			"\n" +
+			"  `--keep` *n*\n" +
+			"\n" +
+			"  Keep the *n* most recent backups when pruning. *n* must not be negative. The\n" +
+			"  default is 10.",
+		example: "" +
+			"    chezmoi backups list\n" +
+			"    chezmoi backups list 20200901T093000Z\n" +
+			"    chezmoi backups restore 20200901T093000Z ~/.bashrc\n" +
+			"    chezmoi backups prune --keep=3",
 	},
 	"cat": {
 		long: "" +
 			"Description:\n" +
 			"  Write the target state of *targets*  to stdout. *targets* must be files or\n" +
 			"  symlinks. For files, the target file contents are written. For symlinks, the\n" +
-			"  target target is written.",
+			"  target target is written.\n" +
+			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.\n" +
+			"\n" +
+			"  `--trace-templates`\n" +
+			"\n" +
+			"  Print a report of template execution to stderr, as described in template\n" +
+			"  execution.",
 		example: "" +
-			"    chezmoi cat ~/.bashrc",
+			"    chezmoi cat ~/.bashrc\n" +
+			"    chezmoi cat --set chezmoi.os=darwin ~/.bashrc\n" +
+			"    chezmoi cat --trace-templates ~/.gitconfig",
 	},
 	"cd": {
 		long: "" +
@@ -140,10 +291,15 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the computed template data in the given format. The accepted formats\n" +
-			"  are `json` (JSON), `toml` (TOML), and `yaml` (YAML).",
+			"  are `json` (JSON), `toml` (TOML), and `yaml` (YAML).\n" +
+			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.",
 		example: "" +
 			"    chezmoi data\n" +
-			"    chezmoi data --format=yaml",
+			"    chezmoi data --format=yaml\n" +
+			"    chezmoi data --profile=work.yaml",
 	},
 	"diff": {
 		long: "" +
@@ -173,11 +329,28 @@ var helps = map[string]help{
 			"\n" +
 			"  `--no-pager`\n" +
 			"\n" +
-			"  Do not use the pager.",
+			"  Do not use the pager.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Only print differences in entries of the included and not excluded *types*,\n" +
+			"  as for apply.\n" +
+			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.\n" +
+			"\n" +
+			"  `--trace-templates`\n" +
+			"\n" +
+			"  Print a report of template execution to stderr, as described in template\n" +
+			"  execution.",
 		example: "" +
 			"    chezmoi diff\n" +
 			"    chezmoi diff ~/.bashrc\n" +
-			"    chezmoi diff --format=git",
+			"    chezmoi diff --format=git\n" +
+			"    chezmoi diff --include=templates\n" +
+			"    chezmoi diff --profile=work.yaml\n" +
+			"    chezmoi diff --trace-templates ~/.gitconfig",
 	},
 	"docs": {
 		long: "" +
@@ -192,7 +365,8 @@ var helps = map[string]help{
 	"doctor": {
 		long: "" +
 			"Description:\n" +
-			"  Check for potential problems.",
+			"  Check for potential problems, including possible secrets in unencrypted\n" +
+			"  files in the source directory.",
 		example: "" +
 			"    chezmoi doctor",
 	},
@@ -205,10 +379,21 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the target state in the given format. The accepted formats are `json`\n" +
-			"  (JSON) and `yaml` (YAML).",
+			"  (JSON) and `yaml` (YAML).\n" +
+			"\n" +
+			"  `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Only dump entries of the included and not excluded *types*, as for apply.\n" +
+			"  Directories containing included entries are always dumped.\n" +
+			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.",
 		example: "" +
 			"    chezmoi dump ~/.bashrc\n" +
-			"    chezmoi dump --format=yaml",
+			"    chezmoi dump --format=yaml\n" +
+			"    chezmoi dump --include=encrypted\n" +
+			"    chezmoi dump --profile=work.yaml",
 	},
 	"edit": {
 		long: "" +
@@ -266,13 +451,25 @@ var helps = map[string]help{
 			"  If `promptString` is called with a *prompt* that does not match any of\n" +
 			"  *pairs*, then it returns *prompt* unchanged.\n" +
 			"\n" +
+			"  `--profile` *filename*, `--set` *key*`=`*value*\n" +
+			"\n" +
+			"  Override the template data, as described in data profiles.\n" +
+			"\n" +
+			"  `--trace-templates`\n" +
+			"\n" +
+			"  Print a report of template execution to stderr, as described in template\n" +
+			"  execution.\n" +
+			"\n" +
 			"  `execute-template` examples\n" +
 			"\n" +
 			"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 			"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
+			"    chezmoi execute-template --set chezmoi.hostname=work-laptop '{{\n" +
+			"  .chezmoi.hostname }}'\n" +
 			"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
 			"    chezmoi execute-template --init --promptString email=john@home.org <\n" +
-			"  ~/.local/share/chezmoi/.chezmoi.toml.tmpl",
+			"  ~/.local/share/chezmoi/.chezmoi.toml.tmpl\n" +
+			"    chezmoi execute-template --trace-templates < dot_gitconfig.tmpl",
 	},
 	"forget": {
 		long: "" +
@@ -361,6 +558,44 @@ var helps = map[string]help{
 			"    chezmoi init https://github.com/user/dotfiles.git\n" +
 			"    chezmoi init https://github.com/user/dotfiles.git --apply",
 	},
+	"lint": {
+		long: "" +
+			"Description:\n" +
+			"  Check the templates in the source state for problems without executing them.\n" +
+			"  All `.tmpl` files, `.chezmoiattributes`, `.chezmoiignore`, and\n" +
+			"  `.chezmoiremove` files, and all files in `.chezmoitemplates` are parsed with\n" +
+			"  chezmoi's template functions. Syntax errors, references to unknown\n" +
+			"  functions, and references to keys that are missing from the template data\n" +
+			"  are reported with their file and line. Templates in `.chezmoitemplates` are\n" +
+			"  not checked for missing keys, as their data is given when they are used.\n" +
+			"  Encrypted files are not checked.\n" +
+			"\n" +
+			"  `lint` also scans the files in the source state that are not templates for\n" +
+			"  the current machine's `.chezmoi.fullHostname`, `.chezmoi.homedir`,\n" +
+			"  `.chezmoi.hostname`, and `.chezmoi.username`, which will be wrong on other\n" +
+			"  machines. Values are found as add --autotemplate would find them, respecting\n" +
+			"  the `autoTemplate.wordBoundary` and `autoTemplate.exclude` configuration\n" +
+			"  variables. For each file that contains one of these values, `lint` prints a\n" +
+			"  warning for each line, followed by the `chezmoi chattr +template` and\n" +
+			"  `chezmoi add --autotemplate` commands that turn the file into a template that\n" +
+			"  uses the variables instead. As these commands replace the file in the source\n" +
+			"  state with the file in the destination directory, `lint` also warns if the\n" +
+			"  two differ, in which case you should run merge first. Encrypted files are\n" +
+			"  not checked.\n" +
+			"\n" +
+			"  If any problems are found then `lint` exits with a non-zero exit status.\n" +
+			"  Warnings about hard-coded values do not change the exit status.\n" +
+			"\n" +
+			"  `--data` *filename*\n" +
+			"\n" +
+			"  Also execute every template with the template data merged with the data in\n" +
+			"  *filename*, and report any errors. *filename* must be a JSON, TOML, or YAML\n" +
+			"  file. This flag can be given multiple times to check against several\n" +
+			"  machines' data. Executing templates may run your secret manager.",
+		example: "" +
+			"    chezmoi lint\n" +
+			"    chezmoi lint --data work.yaml --data home.yaml",
+	},
 	"manage": {
 		long: "" +
 			"Description:\n" +
@@ -374,9 +609,12 @@ var helps = map[string]help{
 			"  `-i`, `--include` *types*\n" +
 			"\n" +
 			"  Only list entries of type *types*. *types* is a comma-separated list of types\n" +
-			"  of entry to include. Valid types are `dirs`, `files`, and `symlinks` which\n" +
-			"  can be abbreviated to `d`, `f`, and `s` respectively. By default, `manage`\n" +
-			"  will list entries of all types.",
+			"  of entry to include. Valid types are as for apply. By default, `managed`\n" +
+			"  will list directories, files, and symlinks.\n" +
+			"\n" +
+			"  `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Do not list entries of type *types*.",
 		example: "" +
 			"    chezmoi managed\n" +
 			"    chezmoi managed --include=files\n" +
@@ -393,9 +631,82 @@ var helps = map[string]help{
 			"  specified the merge tool is invoked for each target. If the target state\n" +
 			"  cannot be computed (for example if source is a template containing errors or\n" +
 			"  an encrypted file that cannot be decrypted) a two-way merge is performed\n" +
-			"  instead.",
+			"  instead.\n" +
+			"\n" +
+			"  If `merge.command` is empty, or `--strategy` is given, chezmoi instead uses\n" +
+			"  its built-in merge, which does not need a terminal. The built-in merge\n" +
+			"  performs a line-based three-way merge, like `diff3`, of the changes to the\n" +
+			"  source state and to the target state, and writes the result to the source\n" +
+			"  file. If the `merge.recordBase` configuration variable is `true` then\n" +
+			"  chezmoi instead merges the changes made since the file was last applied, so\n" +
+			"  that changes to the source state and to the destination state can conflict.\n" +
+			"  To do this, chezmoi stores a copy of the contents of each file that is not a\n" +
+			"  template or encrypted in its persistent state whenever the destination\n" +
+			"  matches the target state after an `apply`, and updates it when the file is\n" +
+			"  added or merged. Only enable this if you are happy for these files' contents\n" +
+			"  to be stored there. Changes that conflict are written to the source file\n" +
+			"  between conflict markers and chezmoi exits with an error, unless they are\n" +
+			"  resolved with `--strategy`. The built-in merge requires the target state to be\n" +
+			"  computed.\n" +
+			"\n" +
+			"  If the source file is a template, the built-in merge instead maps the changes\n" +
+			"  from the target state to the destination state back onto the template's\n" +
+			"  literal text: changed or deleted lines that come from literal text in the\n" +
+			"  template are changed or deleted in the template, and inserted lines are\n" +
+			"  inserted next to the literal text that surrounds them, with any template\n" +
+			"  delimiters escaped. Only the text in the branches of `if` and `with` actions\n" +
+			"  that ran with the current template data is changed. Changes to lines that\n" +
+			"  are generated by template actions, or by text inside `range` loops, cannot\n" +
+			"  be merged automatically. chezmoi prints each such change and exits with an\n" +
+			"  error, unless `--strategy=ours` is given, in which case they are ignored.\n" +
+			"\n" +
+			"  `--autotemplate`\n" +
+			"\n" +
+			"  When merging into a template, replace template data values in the inserted\n" +
+			"  and changed text with the template variables that contain them, as for add --\n" +
+			"  autotemplate.\n" +
+			"\n" +
+			"  `--strategy` *strategy*\n" +
+			"\n" +
+			"  Use the built-in merge and resolve conflicts with *strategy*, which must be\n" +
+			"  one of:\n" +
+			"\n" +
+			"    STRATEGY |           RESOLUTION\n" +
+			"  -----------+---------------------------------\n" +
+			"    ours     | Use the source state's changes\n" +
+			"    theirs   | Use the destination state's\n" +
+			"             | changes\n" +
+			"    union    | Use the source state's changes\n" +
+			"             | followed by the destination\n" +
+			"             | state's",
 		example: "" +
-			"    chezmoi merge ~/.bashrc",
+			"    chezmoi merge ~/.bashrc\n" +
+			"    chezmoi merge --strategy=theirs ~/.bashrc\n" +
+			"    chezmoi merge --autotemplate ~/.gitconfig",
+	},
+	"merge-all": {
+		long: "" +
+			"Description:\n" +
+			"  Perform a three-way merge, as for merge, for every file whose destination\n" +
+			"  state differs from its target state, in turn. Files that match their target\n" +
+			"  state, including templates whose output matches, and files that do not exist\n" +
+			"  in the destination directory are skipped. chezmoi prints a line for each\n" +
+			"  merged file followed by a summary of the number of files merged, skipped,\n" +
+			"  and failed, and exits with an error if any file failed to merge.\n" +
+			"\n" +
+			"  `--autotemplate`\n" +
+			"\n" +
+			"  When merging into templates, replace template data values with template\n" +
+			"  variables, as for merge.\n" +
+			"\n" +
+			"  `--strategy` *strategy*\n" +
+			"\n" +
+			"  Use the built-in merge and resolve conflicts with *strategy*, as for merge.\n" +
+			"\n" +
+			"  `merge-all` examples\n" +
+			"\n" +
+			"    chezmoi merge-all\n" +
+			"    chezmoi merge-all --strategy=ours",
 	},
 	"purge": {
 		long: "" +
@@ -436,14 +747,19 @@ var helps = map[string]help{
 			"\n" +
 			"  To get a full list of available commands run:\n" +
 			"\n" +
-			"    chezmoi secret help",
+			"    chezmoi secret help\n" +
+			"\n" +
+			"  `chezmoi secret cache clear` removes all secrets from the persistent secret\n" +
+			"  cache.",
 		example: "" +
 			"    chezmoi secret bitwarden list items\n" +
+			"    chezmoi secret cache clear\n" +
 			"    chezmoi secret lastpass ls\n" +
 			"    chezmoi secret lastpass -- show --format=json id\n" +
 			"    chezmoi secret onepassword list items\n" +
 			"    chezmoi secret onepassword get item id\n" +
 			"    chezmoi secret pass show id\n" +
+			"    chezmoi secret sops -- --decrypt secrets.sops.yaml\n" +
 			"    chezmoi secret vault -- kv get -format=json id",
 	},
 	"source": {
@@ -513,9 +829,68 @@ var helps = map[string]help{
 			"Description:\n" +
 			"  Verify that all *targets* match their target state. chezmoi exits with code\n" +
 			"  0 (success) if all targets match their target state, or 1 (failure)\n" +
-			"  otherwise. If no targets are specified then all targets are checked.",
+			"  otherwise. If no targets are specified then all targets are checked.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Only verify entries of the included and not excluded *types*, as for apply.",
 		example: "" +
 			"    chezmoi verify\n" +
 			"    chezmoi verify ~/.bashrc",
 	},
+	"watch": {
+		long: "" +
+			"Description:\n" +
+			"  Watch the source directory and, whenever it changes, apply the targets\n" +
+			"  affected by the change. Changes to a source file or directory apply only the\n" +
+			"  corresponding target. Changes to special files and directories like\n" +
+			"  `.chezmoiignore`, `.chezmoidata.<format>`, and `.chezmoitemplates` apply all\n" +
+			"  targets. chezmoi prints a line for each target that it updates and continues\n" +
+			"  watching if the target state cannot be computed, for example because of an\n" +
+			"  error in a template. Press Ctrl-C to stop watching.\n" +
+			"\n" +
+			"  `--add`\n" +
+			"\n" +
+			"  With `--targets`, add each changed target to the source state, as for add,\n" +
+			"  keeping its source attributes. Targets whose source is a template are not\n" +
+			"  added. As with `add`, files that may contain secrets are not added unless\n" +
+			"  they are encrypted or `--allow-secrets` is given.\n" +
+			"\n" +
+			"  `--allow-secrets`\n" +
+			"\n" +
+			"  With `--add`, add files that may contain secrets without encrypting them.\n" +
+			"\n" +
+			"  `--debounce` *duration*\n" +
+			"\n" +
+			"  Wait until no further changes have been made for *duration* before applying\n" +
+			"  them. The default is `100ms`.\n" +
+			"\n" +
+			"  `--encrypt`\n" +
+			"\n" +
+			"  With `--add`, encrypt each added file, as for `add --encrypt`.\n" +
+			"\n" +
+			"  `--merge`\n" +
+			"\n" +
+			"  With `--targets`, run merge on each changed file that is not added by `--add`.\n" +
+			"\n" +
+			"  `--targets`\n" +
+			"\n" +
+			"  Watch the managed files and symlinks in the destination directory instead of\n" +
+			"  the source directory. When a target is changed outside chezmoi so that it no\n" +
+			"  longer matches its target state, chezmoi prints the difference between the\n" +
+			"  target and its target state.\n" +
+			"\n" +
+			"  `--watch-config`\n" +
+			"\n" +
+			"  Also watch the config file. When it changes, chezmoi reads it again and\n" +
+			"  applies all targets.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*, `-x`, `--exclude` *types*\n" +
+			"\n" +
+			"  Only apply entries of the included and not excluded *types*, as for apply.",
+		example: "" +
+			"    chezmoi watch\n" +
+			"    chezmoi watch --watch-config --verbose\n" +
+			"    chezmoi watch --targets --add",
+	},
 }
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var lintCmd = &cobra.Command{
+	Use:     "lint",
+	Args:    cobra.NoArgs,
+	Short:   "Check the templates in the source state for problems",
+	Long:    mustGetLongHelp("lint"),
+	Example: getExample("lint"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runLintCmd,
+}
+
+type lintCmdConfig struct {
+	dataFiles []string
+}
+
+// A lintTemplate is a template in the source state to lint.
+type lintTemplate struct {
+	path     string
+	contents []byte
+	// checkData is true if the template's data is the template data.
+	checkData bool
+	// execute is true if the template is executed directly, rather than
+	// being included by other templates.
+	execute bool
+}
+
+// machineDataNames are the names of the template variables whose values are
+// specific to the current machine.
+var machineDataNames = []string{
+	"chezmoi.fullHostname",
+	"chezmoi.homedir",
+	"chezmoi.hostname",
+	"chezmoi.username",
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	persistentFlags := lintCmd.PersistentFlags()
+	persistentFlags.StringSliceVar(&config.lint.dataFiles, "data", nil, "also execute templates with data from file")
+}
+
+func (c *Config) runLintCmd(cmd *cobra.Command, args []string) error {
+	data, err := c.getData()
+	if err != nil {
+		return err
+	}
+
+	lintTemplates, err := c.getLintTemplates()
+	if err != nil {
+		return err
+	}
+
+	ok := true
+	var executableTemplates []*lintTemplate
+	for _, lt := range lintTemplates {
+		var templateData map[string]interface{}
+		if lt.checkData {
+			templateData = data
+		}
+		problems := chezmoi.LintTemplate(lt.path, string(lt.contents), c.templateFuncs, templateData)
+		for _, problem := range problems {
+			fmt.Fprintf(c.Stdout, "%s:%d: %s\n", lt.path, problem.Line, problem.Message)
+			ok = false
+		}
+		if lt.execute && len(problems) == 0 {
+			executableTemplates = append(executableTemplates, lt)
+		}
+	}
+
+	// The target state cannot be computed if the templates it needs contain
+	// errors, which are already reported.
+	if ts, err := c.getTargetState(nil); err == nil {
+		if err := c.lintHardcodedValues(ts, data); err != nil {
+			return err
+		}
+	} else if ok {
+		return err
+	}
+
+	if len(c.lint.dataFiles) > 0 {
+		templatesDir := filepath.Join(c.SourceDir, ".chezmoitemplates")
+		var templates map[string]*template.Template
+		if _, err := c.fs.Stat(templatesDir); err == nil {
+			if templates, err = chezmoi.ParseTemplatesDir(c.fs, templatesDir, c.Template.Options, c.templateFuncs); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		for _, dataFile := range c.lint.dataFiles {
+			fileData, err := c.readDataFile(dataFile)
+			if err != nil {
+				return err
+			}
+			data, err := c.getData()
+			if err != nil {
+				return err
+			}
+			mergeData(data, fileData)
+			ts := chezmoi.NewTargetState(
+				chezmoi.WithSourceDir(c.SourceDir),
+				chezmoi.WithTemplateData(data),
+				chezmoi.WithTemplateFuncs(c.templateFuncs),
+				chezmoi.WithTemplateOptions(c.Template.Options),
+				chezmoi.WithTemplates(templates),
+			)
+			for _, lt := range executableTemplates {
+				if _, err := ts.ExecuteTemplateData(lt.path, lt.contents); err != nil {
+					fmt.Fprintf(c.Stdout, "%s: %v\n", dataFile, err)
+					ok = false
+				}
+			}
+		}
+	}
+
+	if !ok {
+		return errExitFailure
+	}
+	return nil
+}
+
+// lintHardcodedValues warns about files in ts that are not templates but
+// contain values of template variables that are specific to the current
+// machine, and prints the commands to turn them into templates.
+func (c *Config) lintHardcodedValues(ts *chezmoi.TargetState, data map[string]interface{}) error {
+	autoTemplateOptions, err := c.getAutoTemplateOptions()
+	if err != nil {
+		return err
+	}
+	var files []*chezmoi.File
+	for _, entry := range ts.AllEntries() {
+		if file, ok := entry.(*chezmoi.File); ok && !file.Template && !file.Encrypted {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].SourceName() < files[j].SourceName()
+	})
+	for _, file := range files {
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		problems := chezmoi.LintHardcodedValues(contents, data, machineDataNames, autoTemplateOptions)
+		if len(problems) == 0 {
+			continue
+		}
+		sourcePath := filepath.Join(c.SourceDir, file.SourceName())
+		targetPath := filepath.Join(c.DestDir, file.TargetName())
+		for _, problem := range problems {
+			fmt.Fprintf(c.Stdout, "%s:%d: warning: %s, file will be wrong on other machines\n", sourcePath, problem.Line, problem.Message)
+		}
+		// The suggested fix replaces the source file with the destination file,
+		// so any differences between them must be merged first.
+		quotedTargetPath := chezmoi.MaybeShellQuote(targetPath)
+		if destContents, err := c.fs.ReadFile(targetPath); err != nil || !bytes.Equal(destContents, contents) {
+			fmt.Fprintf(c.Stdout, "%s: warning: %s differs from its target state, merge the differences first with: chezmoi merge %s\n", sourcePath, targetPath, quotedTargetPath)
+		}
+		fmt.Fprintf(c.Stdout, "%s: fix with: chezmoi chattr +template %s && chezmoi add --autotemplate --force %s\n", sourcePath, quotedTargetPath, quotedTargetPath)
+	}
+	return nil
+}
+
+// getLintTemplates returns all the templates in the source state. Encrypted
+// templates are not returned.
+func (c *Config) getLintTemplates() ([]*lintTemplate, error) {
+	var lintTemplates []*lintTemplate
+	templatesDir := filepath.Join(c.SourceDir, ".chezmoitemplates")
+	if err := vfs.Walk(c.fs, c.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == c.SourceDir {
+			return nil
+		}
+		name := info.Name()
+		inTemplatesDir := strings.HasPrefix(path, templatesDir+string(os.PathSeparator))
+		var lt *lintTemplate
+		switch {
+		case path == templatesDir:
+			return nil
+		case inTemplatesDir && info.Mode().IsRegular():
+			lt = &lintTemplate{
+				path: path,
+			}
+		case inTemplatesDir:
+			return nil
+		case strings.HasPrefix(name, "."):
+			switch {
+			case info.IsDir():
+				return filepath.SkipDir
+			case name == ".chezmoiattributes" || name == ".chezmoiignore" || name == ".chezmoiremove":
+				lt = &lintTemplate{
+					path:      path,
+					checkData: true,
+					execute:   true,
+				}
+			default:
+				return nil
+			}
+		case info.Mode().IsRegular() && strings.HasSuffix(name, chezmoi.TemplateSuffix) && !chezmoi.ParseFileAttributes(name).Encrypted:
+			lt = &lintTemplate{
+				path:      path,
+				checkData: true,
+				execute:   true,
+			}
+		default:
+			return nil
+		}
+		contents, err := c.fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		lt.contents = contents
+		lintTemplates = append(lintTemplates, lt)
+		return nil
+	}); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return lintTemplates, nil
+}
@@ -22,50 +22,32 @@ var managedCmd = &cobra.Command{
 
 type managedCmdConfig struct {
 	include []string
+	exclude []string
 }
 
 func init() {
 	rootCmd.AddCommand(managedCmd)
 
 	persistentFlags := managedCmd.PersistentFlags()
-	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include")
+	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks"}, "include entry types")
+	persistentFlags.StringSliceVarP(&config.managed.exclude, "exclude", "x", nil, "exclude entry types")
 }
 
 func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
-	ts, err := c.getTargetState(nil)
+	include, err := chezmoi.NewIncludeSet(c.managed.include, c.managed.exclude)
 	if err != nil {
 		return err
 	}
-
-	var (
-		includeDirs     = false
-		includeFiles    = false
-		includeSymlinks = false
-	)
-	for _, what := range c.managed.include {
-		switch what {
-		case "dirs", "d":
-			includeDirs = true
-		case "files", "f":
-			includeFiles = true
-		case "symlinks", "s":
-			includeSymlinks = true
-		default:
-			return fmt.Errorf("unrecognized include: %q", what)
-		}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
 	}
 
 	allEntries := ts.AllEntries()
 
 	targetNames := make([]string, 0, len(allEntries))
 	for _, entry := range allEntries {
-		if _, ok := entry.(*chezmoi.Dir); ok && !includeDirs {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.File); ok && !includeFiles {
-			continue
-		}
-		if _, ok := entry.(*chezmoi.Symlink); ok && !includeSymlinks {
+		if !include.IncludeEntry(entry) {
 			continue
 		}
 		targetNames = append(targetNames, entry.TargetName())
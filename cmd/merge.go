@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -22,13 +24,23 @@ var mergeCmd = &cobra.Command{
 }
 
 type mergeConfig struct {
-	Command string
-	Args    []string
+	Command    string
+	Args       []string
+	RecordBase bool
+}
+
+type mergeCmdConfig struct {
+	autoTemplate bool
+	strategy     string
 }
 
 func init() {
 	rootCmd.AddCommand(mergeCmd)
 
+	persistentFlags := mergeCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.merge.autoTemplate, "autotemplate", false, "replace template data values in text added to templates with template variables")
+	persistentFlags.StringVar(&config.merge.strategy, "strategy", "", "merge with the built-in merge, resolving conflicts with strategy (ours, theirs, or union)")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(mergeCmd, 1)
 }
 
@@ -53,7 +65,7 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 	defer os.RemoveAll(tempDir)
 
 	for i, entry := range entries {
-		if err := c.runMergeCommand(cmd, args[i], entry, tempDir); err != nil {
+		if err := c.runMergeCommand(cmd, ts, args[i], entry, tempDir); err != nil {
 			return err
 		}
 	}
@@ -61,12 +73,16 @@ func (c *Config) runMergeCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func (c *Config) runMergeCommand(cmd *cobra.Command, arg string, entry chezmoi.Entry, tempDir string) error {
+func (c *Config) runMergeCommand(cmd *cobra.Command, ts *chezmoi.TargetState, arg string, entry chezmoi.Entry, tempDir string) error {
 	file, ok := entry.(*chezmoi.File)
 	if !ok {
 		return fmt.Errorf("%s: not a file", arg)
 	}
 
+	if c.merge.strategy != "" || c.Merge.Command == "" {
+		return c.runBuiltinMerge(ts, arg, file)
+	}
+
 	// By default, perform a two-way merge between the destination state and the
 	// source state.
 	args := append(
@@ -95,3 +111,200 @@ func (c *Config) runMergeCommand(cmd *cobra.Command, arg string, entry chezmoi.E
 
 	return nil
 }
+
+// runBuiltinMerge performs a three-way merge of the changes to the source state
+// and to the destination state of file since it was last applied, and writes
+// the result to the source state. If the contents of file when it was last
+// applied are not known then the target state is used instead. If file is a
+// template, the changes from the target state to the destination state are
+// instead mapped back onto the template's literal text.
+func (c *Config) runBuiltinMerge(ts *chezmoi.TargetState, arg string, file *chezmoi.File) error {
+	strategy, err := chezmoi.ParseMergeStrategy(c.merge.strategy)
+	if err != nil {
+		return err
+	}
+
+	targetContents, err := file.Contents()
+	if err != nil {
+		return fmt.Errorf("%s: cannot evaluate target state: %w", arg, err)
+	}
+	destPath := filepath.Join(c.DestDir, file.TargetName())
+	destContents, err := c.fs.ReadFile(destPath)
+	if err != nil {
+		return err
+	}
+	sourcePath := filepath.Join(c.SourceDir, file.SourceName())
+	sourceData, err := c.fs.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	sourceContents := sourceData
+	if file.Encrypted {
+		sourceContents, err = c.GPG.Decrypt(sourcePath, sourceData)
+		if err != nil {
+			return err
+		}
+	}
+
+	var merged []byte
+	var conflicts int
+	var unresolved []chezmoi.ReverseTemplateHunk
+	if file.Template {
+		var reverseTemplateOptions chezmoi.ReverseTemplateOptions
+		if c.merge.autoTemplate {
+			reverseTemplateOptions.AutoTemplateData, err = c.getData()
+			if err != nil {
+				return err
+			}
+			reverseTemplateOptions.AutoTemplateOptions, err = c.getAutoTemplateOptions()
+			if err != nil {
+				return err
+			}
+		}
+		merged, unresolved, err = ts.ReverseTemplate(sourcePath, sourceContents, targetContents, destContents, reverseTemplateOptions)
+		if err != nil {
+			return err
+		}
+	} else {
+		baseContents, baseLabel := targetContents, "target state"
+		if !file.Encrypted {
+			mergeBase, err := c.getMergeBase(file.TargetName())
+			if err != nil {
+				return err
+			}
+			if mergeBase != nil {
+				baseContents, baseLabel = mergeBase, "last applied"
+			}
+		}
+		merged, conflicts = chezmoi.Merge3(baseContents, sourceContents, destContents, chezmoi.MergeOptions{
+			OursLabel:   sourcePath,
+			BaseLabel:   baseLabel,
+			TheirsLabel: destPath,
+			Strategy:    strategy,
+		})
+	}
+	if !bytes.Equal(merged, sourceContents) {
+		if file.Encrypted {
+			merged, err = c.GPG.Encrypt(sourcePath, merged)
+			if err != nil {
+				return err
+			}
+		}
+		if err := c.mutator.WriteFile(sourcePath, merged, 0o666&^os.FileMode(c.Umask), sourceData); err != nil {
+			return err
+		}
+	}
+
+	if conflicts > 0 && strategy == chezmoi.MergeStrategyConflict {
+		return fmt.Errorf("%s: %d conflicts written to %s", arg, conflicts, sourcePath)
+	}
+	if !file.Template && !file.Encrypted {
+		// The destination's changes are now in the source state, so later
+		// merges only need to consider changes made after this one.
+		if err := c.setMergeBase(file.TargetName(), destContents); err != nil {
+			return err
+		}
+	}
+	if len(unresolved) > 0 && strategy != chezmoi.MergeStrategyOurs {
+		for _, hunk := range unresolved {
+			fmt.Fprintf(c.Stderr, "%s:%d: change does not come from literal text in %s\n", arg, hunk.Line, sourcePath)
+			for _, line := range hunk.OldLines {
+				fmt.Fprintf(c.Stderr, "-%s", ensureNewline(line))
+			}
+			for _, line := range hunk.NewLines {
+				fmt.Fprintf(c.Stderr, "+%s", ensureNewline(line))
+			}
+		}
+		return fmt.Errorf("%s: %d changes must be merged manually into %s", arg, len(unresolved), sourcePath)
+	}
+	return nil
+}
+
+// getMergeBase returns the contents of the target targetName when it was last
+// applied, or nil if they are not known.
+func (c *Config) getMergeBase(targetName string) ([]byte, error) {
+	if !c.Merge.RecordBase {
+		return nil, nil
+	}
+	var mergeBase []byte
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		var err error
+		mergeBase, err = persistentState.Get(c.mergeBaseBucket, []byte(targetName))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return mergeBase, nil
+}
+
+// recordMergeBases records the contents of each file in allEntries whose
+// destination matches its target state as the base of later merges. The
+// destination is checked after applying, rather than assuming that every
+// change was made, as apply --interactive lets the user decline changes.
+// Templates and encrypted files are not recorded, as their contents may contain
+// secrets.
+func (c *Config) recordMergeBases(ts *chezmoi.TargetState, include *chezmoi.IncludeSet, allEntries []chezmoi.Entry) error {
+	if !c.Merge.RecordBase || c.DryRun {
+		return nil
+	}
+	mergeBases := make(map[string][]byte)
+	for _, entry := range allEntries {
+		file, ok := entry.(*chezmoi.File)
+		if !ok || file.Template || file.Encrypted || ts.TargetIgnore.Match(file.TargetName()) || !include.IncludeEntry(file) {
+			continue
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		destContents, err := c.fs.ReadFile(filepath.Join(ts.DestDir, file.TargetName()))
+		if err != nil || !bytes.Equal(destContents, contents) {
+			continue
+		}
+		mergeBases[file.TargetName()] = contents
+	}
+	if len(mergeBases) == 0 {
+		return nil
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		for targetName, contents := range mergeBases {
+			mergeBase, err := persistentState.Get(c.mergeBaseBucket, []byte(targetName))
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(mergeBase, contents) {
+				continue
+			}
+			if err := persistentState.Set(c.mergeBaseBucket, []byte(targetName), contents); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// setMergeBase records contents as the base of later merges of the target
+// targetName. If contents is nil then any recorded base is removed, so that
+// later merges use the target state.
+func (c *Config) setMergeBase(targetName string, contents []byte) error {
+	if !c.Merge.RecordBase || c.DryRun {
+		return nil
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		if contents == nil {
+			if mergeBase, err := persistentState.Get(c.mergeBaseBucket, []byte(targetName)); err != nil || mergeBase == nil {
+				return err
+			}
+			return persistentState.Delete(c.mergeBaseBucket, []byte(targetName))
+		}
+		return persistentState.Set(c.mergeBaseBucket, []byte(targetName), contents)
+	})
+}
+
+// ensureNewline returns s with a trailing newline.
+func ensureNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var mergeAllCmd = &cobra.Command{
+	Use:     "merge-all",
+	Args:    cobra.NoArgs,
+	Short:   "Perform a three-way merge for every file whose destination state differs from its target state",
+	Long:    mustGetLongHelp("merge-all"),
+	Example: getExample("merge-all"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runMergeAllCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(mergeAllCmd)
+
+	persistentFlags := mergeAllCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.merge.autoTemplate, "autotemplate", false, "replace template data values in text added to templates with template variables")
+	persistentFlags.StringVar(&config.merge.strategy, "strategy", "", "merge with the built-in merge, resolving conflicts with strategy (ours, theirs, or union)")
+}
+
+func (c *Config) runMergeAllCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	var files []*chezmoi.File
+	for _, entry := range ts.AllEntries() {
+		if file, ok := entry.(*chezmoi.File); ok && !ts.TargetIgnore.Match(file.TargetName()) {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].TargetName() < files[j].TargetName()
+	})
+
+	// Create a temporary directory to store the target state and ensure that it
+	// is removed afterwards. We cannot use fs as it lacks TempDir
+	// functionality.
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	merged, skipped, failed := 0, 0, 0
+	for _, file := range files {
+		targetPath := filepath.Join(ts.DestDir, file.TargetName())
+		diverged, err := c.fileDiverged(file, targetPath)
+		if err == nil && !diverged {
+			skipped++
+			continue
+		}
+		if err == nil {
+			err = c.runMergeCommand(cmd, ts, targetPath, file, tempDir)
+		}
+		if err != nil {
+			fmt.Fprintf(c.Stderr, "chezmoi: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(c.Stdout, "merged %s\n", targetPath)
+		merged++
+	}
+
+	fmt.Fprintf(c.Stdout, "%d merged, %d skipped, %d failed\n", merged, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d targets failed to merge", failed)
+	}
+	return nil
+}
+
+// fileDiverged returns whether the contents of the file at targetPath differ
+// from file's target state. Files that do not exist have not diverged.
+func (c *Config) fileDiverged(file *chezmoi.File, targetPath string) (bool, error) {
+	destContents, err := c.fs.ReadFile(targetPath)
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	targetContents, err := file.Contents()
+	if err != nil {
+		return false, fmt.Errorf("%s: cannot evaluate target state: %w", targetPath, err)
+	}
+	return !bytes.Equal(destContents, targetContents), nil
+}
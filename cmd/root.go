@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -31,11 +32,12 @@ var (
 )
 
 var rootCmd = &cobra.Command{
-	Use:               "chezmoi",
-	Short:             "Manage your dotfiles across multiple machines, securely",
-	SilenceErrors:     true,
-	SilenceUsage:      true,
-	PersistentPreRunE: config.persistentPreRunRootE,
+	Use:                "chezmoi",
+	Short:              "Manage your dotfiles across multiple machines, securely",
+	SilenceErrors:      true,
+	SilenceUsage:       true,
+	PersistentPreRunE:  config.persistentPreRunRootE,
+	PersistentPostRunE: config.persistentPostRunRootE,
 }
 
 var (
@@ -87,6 +89,15 @@ func init() {
 	persistentFlags.BoolVar(&config.Debug, "debug", false, "write debug logs")
 	panicOnError(viper.BindPFlag("debug", persistentFlags.Lookup("debug")))
 
+	persistentFlags.BoolVar(&config.showSecrets, "show-secrets", false, "show secrets in output")
+
+	persistentFlags.StringVar(&config.LogFile, "log-file", "", "write a log of changes to file")
+	panicOnError(viper.BindPFlag("log-file", persistentFlags.Lookup("log-file")))
+	panicOnError(rootCmd.MarkPersistentFlagFilename("log-file"))
+
+	persistentFlags.StringVar(&config.LogFormat, "log-format", "json", "log format")
+	panicOnError(viper.BindPFlag("log-format", persistentFlags.Lookup("log-format")))
+
 	cobra.OnInitialize(func() {
 		_, err := os.Stat(config.configFile)
 		switch {
@@ -99,6 +110,9 @@ func init() {
 			if config.err == nil {
 				config.err = config.validateData()
 			}
+			if config.err == nil {
+				config.err = config.addSecretProviderTemplateFuncs()
+			}
 			if config.err != nil {
 				rootCmd.Printf("warning: %s: %v\n", config.configFile, config.err)
 			}
@@ -153,6 +167,13 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+func (c *Config) persistentPostRunRootE(cmd *cobra.Command, args []string) error {
+	if c.logFile != nil {
+		return c.logFile.Close()
+	}
+	return nil
+}
+
 func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error {
 	if colored, err := strconv.ParseBool(c.Color); err == nil {
 		c.colored = colored
@@ -186,11 +207,32 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 	if c.DryRun {
 		c.mutator = chezmoi.NullMutator{}
 	}
+	backupMutator := c.mutator
 	if c.Debug {
-		c.mutator = chezmoi.NewDebugMutator(c.mutator)
+		c.mutator = chezmoi.NewDebugMutator(c.mutator, c.getRedactor())
 	}
 	if c.Verbose {
-		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.getRedactor())
+	}
+	if len(c.Escalate.Paths) > 0 {
+		c.mutator = chezmoi.NewEscalatingMutator(c.mutator, c.Escalate.Command, c.Escalate.Args, c.Escalate.Paths)
+	}
+	if c.Backup.Dir != "" {
+		// Back up targets before any escalated changes, which do not call
+		// the mutators that the EscalatingMutator wraps.
+		backupDir := filepath.Join(c.Backup.Dir, time.Now().UTC().Format(chezmoi.BackupTimeFormat))
+		c.mutator = chezmoi.NewBackupMutator(c.Stderr, c.mutator, backupMutator, c.fs, c.DestDir, backupDir, c.ignoreBackup)
+	}
+	if c.LogFile != "" {
+		if c.LogFormat != "json" {
+			return fmt.Errorf("invalid --log-format value: %s", c.LogFormat)
+		}
+		logFile, err := os.OpenFile(c.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		c.logFile = logFile
+		c.mutator = chezmoi.NewJSONLogMutator(c.logFile, c.mutator, c.fs)
 	}
 
 	if runtime.GOOS == "linux" && c.bds.RuntimeDir != "" {
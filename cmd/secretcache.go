@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var secretCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Args:  cobra.NoArgs,
+	Short: "Manage the persistent secret cache",
+}
+
+var secretCacheClearCmd = &cobra.Command{
+	Use:     "clear",
+	Args:    cobra.NoArgs,
+	Short:   "Remove all secrets from the persistent secret cache",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSecretCacheClearCmd,
+}
+
+type secretCacheConfig struct {
+	KeyFile string
+	TTL     map[string]time.Duration
+}
+
+// A secretCacheRecord is a secret command's output stored in the persistent
+// state.
+type secretCacheRecord struct {
+	CreatedAt  time.Time `json:"createdAt"`
+	Ciphertext []byte    `json:"ciphertext"`
+}
+
+var (
+	secretCacheBucket = []byte("secretCache")
+
+	errNoSecretCacheEncryption = errors.New("secretCache: neither secretCache.keyFile nor gpg.recipient set")
+)
+
+func init() {
+	secretCacheCmd.AddCommand(secretCacheClearCmd)
+	secretCmd.AddCommand(secretCacheCmd)
+}
+
+func (c *Config) runSecretCacheClearCmd(cmd *cobra.Command, args []string) error {
+	if c.DryRun {
+		return nil
+	}
+	return c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		return persistentState.DeleteBucket(secretCacheBucket)
+	})
+}
+
+// A secretCache is a cache of secret values that is safe for concurrent use.
+// Each value is computed at most once, even if it is requested by several
+// goroutines at the same time.
+type secretCache struct {
+	mutex   sync.Mutex
+	entries map[string]*secretCacheEntry
+}
+
+// A secretCacheEntry is a single value in a secretCache.
+type secretCacheEntry struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// newSecretCache returns a new, empty secretCache.
+func newSecretCache() *secretCache {
+	return &secretCache{
+		entries: make(map[string]*secretCacheEntry),
+	}
+}
+
+// get returns the value associated with key, calling f to compute it if it is
+// not already cached. Errors returned by f are cached too, so f is called at
+// most once for each key.
+func (c *secretCache) get(key string, f func() (interface{}, error)) (interface{}, error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &secretCacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mutex.Unlock()
+	entry.once.Do(func() {
+		entry.value, entry.err = f()
+	})
+	return entry.value, entry.err
+}
+
+// ttl returns the time to live of provider's secrets in the persistent secret
+// cache. Configuration keys are case insensitive.
+func (sc secretCacheConfig) ttl(provider string) time.Duration {
+	for key, ttl := range sc.TTL {
+		if strings.EqualFold(key, provider) {
+			return ttl
+		}
+	}
+	return 0
+}
+
+// secretOutput returns the output of provider's command with args, calling f
+// to run the command if needed. Outputs are cached for the lifetime of c, and
+// in the persistent secret cache if it is enabled for provider.
+func (c *Config) secretOutput(provider string, args []string, f func() ([]byte, error)) ([]byte, error) {
+	output, err := c.secretOutputCache.get(provider+"\x00"+strings.Join(args, "\x00"), func() (interface{}, error) {
+		return c.persistentSecretOutput(provider, args, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.([]byte), nil
+}
+
+// persistentSecretOutput returns the output of provider's command with args.
+// If provider has a time to live in the persistent secret cache then the
+// output is read from the persistent secret cache if it is fresh, otherwise f
+// is called and its output is stored in the persistent secret cache, unless
+// this is a dry run.
+func (c *Config) persistentSecretOutput(provider string, args []string, f func() ([]byte, error)) ([]byte, error) {
+	ttl := c.SecretCache.ttl(provider)
+	if ttl <= 0 {
+		return f()
+	}
+
+	keyArr := sha256.Sum256([]byte(provider + "\x00" + strings.Join(args, "\x00")))
+	key := []byte(hex.EncodeToString(keyArr[:]))
+
+	var data []byte
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		var err error
+		data, err = persistentState.Get(secretCacheBucket, key)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if data != nil {
+		var record secretCacheRecord
+		if err := json.Unmarshal(data, &record); err == nil && time.Since(record.CreatedAt) < ttl {
+			if output, err := c.decryptSecretCacheValue(record.Ciphertext); err == nil {
+				return output, nil
+			}
+		}
+	}
+
+	output, err := f()
+	if err != nil {
+		return nil, err
+	}
+	if c.DryRun {
+		return output, nil
+	}
+	ciphertext, err := c.encryptSecretCacheValue(output)
+	if err != nil {
+		return nil, err
+	}
+	data, err = json.Marshal(&secretCacheRecord{
+		CreatedAt:  time.Now(),
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.withPersistentState(func(persistentState chezmoi.PersistentState) error {
+		return persistentState.Set(secretCacheBucket, key, data)
+	}); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// decryptSecretCacheValue decrypts a value from the persistent secret cache.
+func (c *Config) decryptSecretCacheValue(ciphertext []byte) ([]byte, error) {
+	if c.SecretCache.KeyFile == "" {
+		gpg := c.getSecretCacheGPG()
+		if gpg == nil {
+			return nil, errNoSecretCacheEncryption
+		}
+		return gpg.Decrypt("secret", ciphertext)
+	}
+	aead, err := c.getSecretCacheAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("secretCache: ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// encryptSecretCacheValue encrypts a value for the persistent secret cache.
+func (c *Config) encryptSecretCacheValue(plaintext []byte) ([]byte, error) {
+	if c.SecretCache.KeyFile == "" {
+		gpg := c.getSecretCacheGPG()
+		if gpg == nil {
+			return nil, errNoSecretCacheEncryption
+		}
+		return gpg.Encrypt("secret", plaintext)
+	}
+	aead, err := c.getSecretCacheAEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// getSecretCacheAEAD returns the cipher used to encrypt the persistent secret
+// cache, using a key derived from the contents of secretCache.keyFile.
+func (c *Config) getSecretCacheAEAD() (cipher.AEAD, error) {
+	keyData, err := c.fs.ReadFile(c.SecretCache.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("secretCache: %w", err)
+	}
+	if len(keyData) == 0 {
+		return nil, fmt.Errorf("secretCache: %s: empty key file", c.SecretCache.KeyFile)
+	}
+	key := sha256.Sum256(keyData)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// getSecretCacheGPG returns the GPG configuration used to encrypt the
+// persistent secret cache, or nil if GPG encryption is not configured.
+func (c *Config) getSecretCacheGPG() *chezmoi.GPG {
+	gpg := c.GPG
+	// For backwards compatibility, prioritize gpgRecipient over gpg.recipient.
+	if c.GPGRecipient != "" {
+		gpg.Recipient = c.GPGRecipient
+	}
+	if gpg.Recipient == "" && !gpg.Symmetric {
+		return nil
+	}
+	return &gpg
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestSecretCache(t *testing.T) {
+	c := newSecretCache()
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.get("key", func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return "value", nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "value", value)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.get("error", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.New("error")
+		})
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestPersistentSecretOutput(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi/key": "# contents of key\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withSecretCacheConfig(secretCacheConfig{
+			KeyFile: "/home/user/.config/chezmoi/key",
+			TTL: map[string]time.Duration{
+				"genericsecret": time.Hour,
+			},
+		}),
+	)
+
+	calls := 0
+	secret := func() ([]byte, error) {
+		calls++
+		return []byte("hunter2"), nil
+	}
+	for _, tc := range []struct {
+		provider      string
+		args          []string
+		expectedCalls int
+	}{
+		{provider: "genericSecret", args: []string{"a"}, expectedCalls: 1},
+		{provider: "genericSecret", args: []string{"a"}, expectedCalls: 1},
+		{provider: "genericSecret", args: []string{"b"}, expectedCalls: 2},
+		{provider: "pass", args: []string{"a"}, expectedCalls: 3},
+		{provider: "pass", args: []string{"a"}, expectedCalls: 4},
+	} {
+		output, err := c.persistentSecretOutput(tc.provider, tc.args, secret)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("hunter2"), output)
+		assert.Equal(t, tc.expectedCalls, calls)
+	}
+
+	data, err := fs.ReadFile("/home/user/.config/chezmoi/chezmoistate.boltdb")
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(data, []byte("hunter2")))
+
+	require.NoError(t, c.runSecretCacheClearCmd(nil, nil))
+	_, err = c.persistentSecretOutput("genericSecret", []string{"a"}, secret)
+	require.NoError(t, err)
+	assert.Equal(t, 5, calls)
+
+	c.SecretCache.TTL["genericsecret"] = time.Nanosecond
+	_, err = c.persistentSecretOutput("genericSecret", []string{"a"}, secret)
+	require.NoError(t, err)
+	assert.Equal(t, 6, calls)
+
+	// In dry run mode, the persistent state is not modified.
+	c.SecretCache.TTL["genericsecret"] = time.Hour
+	c.DryRun = true
+	data, err = fs.ReadFile("/home/user/.config/chezmoi/chezmoistate.boltdb")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = c.persistentSecretOutput("genericSecret", []string{"c"}, secret)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 8, calls)
+	require.NoError(t, c.runSecretCacheClearCmd(nil, nil))
+	_, err = c.persistentSecretOutput("genericSecret", []string{"a"}, secret)
+	require.NoError(t, err)
+	assert.Equal(t, 8, calls)
+	dryRunData, err := fs.ReadFile("/home/user/.config/chezmoi/chezmoistate.boltdb")
+	require.NoError(t, err)
+	assert.Equal(t, data, dryRunData)
+}
@@ -1,17 +1,6 @@
 package cmd
 
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/exec"
-	"strings"
-
-	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
-)
+import "github.com/spf13/cobra"
 
 var genericSecretCmd = &cobra.Command{
 	Use:     "generic [args...]",
@@ -24,14 +13,9 @@ type genericSecretCmdConfig struct {
 	Command string
 }
 
-var (
-	secretCache     = make(map[string]string)
-	secretJSONCache = make(map[string]interface{})
-)
-
 func init() {
-	config.addTemplateFunc("secret", config.secretFunc)
-	config.addTemplateFunc("secretJSON", config.secretJSONFunc)
+	config.addSecretTemplateFunc("secret", config.secretFunc)
+	config.addSecretTemplateFunc("secretJSON", config.secretJSONFunc)
 
 	secretCmd.AddCommand(genericSecretCmd)
 }
@@ -41,40 +25,21 @@ func (c *Config) runGenericSecretCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) secretFunc(args ...string) string {
-	key := strings.Join(args, "\x00")
-	if value, ok := secretCache[key]; ok {
-		return value
-	}
-	name := c.GenericSecret.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-	value := string(bytes.TrimSpace(output))
-	secretCache[key] = value
-	return value
+	value, err := c.getSecret(c.genericSecretProvider(parseRawSecretOutput), args)
+	panicOnError(err)
+	return value.(string)
 }
 
 func (c *Config) secretJSONFunc(args ...string) interface{} {
-	key := strings.Join(args, "\x00")
-	if value, ok := secretJSONCache[key]; ok {
-		return value
-	}
-	name := c.GenericSecret.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-	var value interface{}
-	if err := json.Unmarshal(output, &value); err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-	secretJSONCache[key] = value
+	value, err := c.getSecret(c.genericSecretProvider(parseJSONSecretOutput), args)
+	panicOnError(err)
 	return value
 }
+
+func (c *Config) genericSecretProvider(parseOutput func([]byte) (interface{}, error)) SecretProvider {
+	return &cmdSecretProvider{
+		name:        "genericSecret",
+		command:     c.GenericSecret.Command,
+		parseOutput: parseOutput,
+	}
+}
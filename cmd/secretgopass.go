@@ -1,14 +1,6 @@
 package cmd
 
-import (
-	"bytes"
-	"fmt"
-	"os/exec"
-
-	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
-)
+import "github.com/spf13/cobra"
 
 var gopassCmd = &cobra.Command{
 	Use:     "gopass [args...]",
@@ -21,13 +13,11 @@ type gopassCmdConfig struct {
 	Command string
 }
 
-var gopassCache = make(map[string]string)
-
 func init() {
 	secretCmd.AddCommand(gopassCmd)
 
 	config.Gopass.Command = "gopass"
-	config.addTemplateFunc("gopass", config.gopassFunc)
+	config.addSecretTemplateFunc("gopass", config.gopassFunc)
 }
 
 func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
@@ -35,22 +25,12 @@ func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) gopassFunc(id string) string {
-	if s, ok := gopassCache[id]; ok {
-		return s
-	}
-	name := c.Gopass.Command
-	args := []string{"show", id}
-	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
-	}
-	var password string
-	if index := bytes.IndexByte(output, '\n'); index != -1 {
-		password = string(output[:index])
-	} else {
-		password = string(output)
-	}
-	gopassCache[id] = password
-	return gopassCache[id]
+	password, err := c.getSecret(&cmdSecretProvider{
+		name:        "gopass",
+		command:     c.Gopass.Command,
+		args:        []string{"show"},
+		parseOutput: parseFirstLineSecretOutput,
+	}, []string{id})
+	panicOnError(err)
+	return password.(string)
 }
@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -31,15 +32,23 @@ type keePassXCCmdConfig struct {
 	Args     []string
 }
 
-type keePassXCAttributeCacheKey struct {
-	entry     string
-	attribute string
+// A keePassXCSecretProvider is a SecretProvider that runs keepassxc-cli.
+type keePassXCSecretProvider struct {
+	c           *Config
+	args        []string
+	parseOutput func([]byte) (interface{}, error)
+}
+
+// A keePassXCPasswordReader is an io.Reader that reads the database password
+// followed by a newline.
+type keePassXCPasswordReader struct {
+	c *Config
+	r io.Reader
 }
 
 var (
+	keePassXCMutex                       sync.Mutex // protects keePassXCVersion and keePassXCPassword
 	keePassXCVersion                     *semver.Version
-	keePassXCCache                       = make(map[string]map[string]string)
-	keePassXCAttributeCache              = make(map[keePassXCAttributeCacheKey]string)
 	keePassXCPairRegexp                  = regexp.MustCompile(`^([^:]+): (.*)$`)
 	keePassXCPassword                    string
 	keePassXCNeedShowProtectedArgVersion = semver.Version{Major: 2, Minor: 5, Patch: 1}
@@ -47,8 +56,8 @@ var (
 
 func init() {
 	config.KeePassXC.Command = "keepassxc-cli"
-	config.addTemplateFunc("keepassxc", config.keePassXCFunc)
-	config.addTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
+	config.addPasswordManagerTemplateFunc("keepassxc", config.keePassXCFunc)
+	config.addSecretTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
 
 	secretCmd.AddCommand(keePassXCCmd)
 }
@@ -58,6 +67,8 @@ func (c *Config) runKeePassXCCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) getKeePassXCVersion() *semver.Version {
+	keePassXCMutex.Lock()
+	defer keePassXCMutex.Unlock()
 	if keePassXCVersion != nil {
 		return keePassXCVersion
 	}
@@ -76,56 +87,69 @@ func (c *Config) getKeePassXCVersion() *semver.Version {
 }
 
 func (c *Config) keePassXCFunc(entry string) map[string]string {
-	if data, ok := keePassXCCache[entry]; ok {
-		return data
-	}
-	if c.KeePassXC.Database == "" {
-		panic(errors.New("keepassxc.database not set"))
-	}
-	name := c.KeePassXC.Command
-	args := []string{"show"}
-	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
-		args = append(args, "--show-protected")
-	}
-	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
-	output, err := c.runKeePassXCCLICommand(name, args)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
-	}
-	data, err := parseKeyPassXCOutput(output)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
-	}
-	keePassXCCache[entry] = data
-	return data
+	data, err := c.getSecret(c.newKeePassXCSecretProvider([]string{"show"}, func(output []byte) (interface{}, error) {
+		return parseKeyPassXCOutput(output)
+	}), []string{entry})
+	panicOnError(err)
+	return data.(map[string]string)
 }
 
 func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
-	key := keePassXCAttributeCacheKey{
-		entry:     entry,
-		attribute: attribute,
-	}
-	if data, ok := keePassXCAttributeCache[key]; ok {
-		return data
-	}
+	value, err := c.getSecret(c.newKeePassXCSecretProvider([]string{"show", "--attributes", attribute, "--quiet"}, parseRawSecretOutput), []string{entry})
+	panicOnError(err)
+	return value.(string)
+}
+
+// newKeePassXCSecretProvider returns a new keePassXCSecretProvider that runs
+// keepassxc-cli with args, followed by the database, and parses its output
+// with parseOutput.
+func (c *Config) newKeePassXCSecretProvider(args []string, parseOutput func([]byte) (interface{}, error)) *keePassXCSecretProvider {
 	if c.KeePassXC.Database == "" {
 		panic(errors.New("keepassxc.database not set"))
 	}
-	name := c.KeePassXC.Command
-	args := []string{"show", "--attributes", attribute, "--quiet"}
 	if c.getKeePassXCVersion().Compare(keePassXCNeedShowProtectedArgVersion) >= 0 {
 		args = append(args, "--show-protected")
 	}
 	args = append(args, c.KeePassXC.Args...)
-	args = append(args, c.KeePassXC.Database, entry)
-	output, err := c.runKeePassXCCLICommand(name, args)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
+	args = append(args, c.KeePassXC.Database)
+	return &keePassXCSecretProvider{
+		c:           c,
+		args:        args,
+		parseOutput: parseOutput,
+	}
+}
+
+// Name implements SecretProvider.Name.
+func (p *keePassXCSecretProvider) Name() string {
+	return "keepassxc"
+}
+
+// Cmd implements SecretProvider.Cmd. The database password is only read when
+// the command reads its stdin, so that it is not prompted for when the
+// command's output is cached.
+func (p *keePassXCSecretProvider) Cmd(args []string) (*exec.Cmd, error) {
+	//nolint:gosec
+	cmd := exec.Command(p.c.KeePassXC.Command, append(append([]string{}, p.args...), args...)...)
+	cmd.Stdin = &keePassXCPasswordReader{c: p.c}
+	cmd.Stderr = p.c.Stderr
+	return cmd, nil
+}
+
+// ParseOutput implements SecretProvider.ParseOutput.
+func (p *keePassXCSecretProvider) ParseOutput(output []byte) (interface{}, error) {
+	return p.parseOutput(output)
+}
+
+// Read implements io.Reader.Read.
+func (r *keePassXCPasswordReader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		password, err := r.c.getKeePassXCPassword()
+		if err != nil {
+			return 0, err
+		}
+		r.r = strings.NewReader(password + "\n")
 	}
-	outputStr := strings.TrimSpace(string(output))
-	keePassXCAttributeCache[key] = outputStr
-	return outputStr
+	return r.r.Read(p)
 }
 
 func readPassword(prompt string) (pw []byte, err error) {
@@ -162,19 +186,20 @@ func readPassword(prompt string) (pw []byte, err error) {
 	}
 }
 
-func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
+// getKeePassXCPassword returns the database password, prompting for it the
+// first time.
+func (c *Config) getKeePassXCPassword() (string, error) {
+	keePassXCMutex.Lock()
+	defer keePassXCMutex.Unlock()
 	if keePassXCPassword == "" {
 		password, err := readPassword(fmt.Sprintf("Insert password to unlock %s: ", c.KeePassXC.Database))
 		fmt.Println()
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		keePassXCPassword = string(password)
 	}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
-	cmd.Stderr = c.Stderr
-	return c.mutator.IdempotentCmdOutput(cmd)
+	return keePassXCPassword, nil
 }
 
 func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
@@ -186,7 +211,7 @@ func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
 		}
 		match := keePassXCPairRegexp.FindStringSubmatch(s.Text())
 		if match == nil {
-			return nil, fmt.Errorf("cannot parse %q", s.Text())
+			return nil, fmt.Errorf("line %d: cannot parse", i+1)
 		}
 		data[match[1]] = match[2]
 	}
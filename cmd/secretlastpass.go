@@ -38,12 +38,10 @@ type lastpassCmdConfig struct {
 	versionCheckOnce sync.Once
 }
 
-var lastPassCache = make(map[string][]map[string]interface{})
-
 func init() {
 	config.Lastpass.Command = "lpass"
-	config.addTemplateFunc("lastpass", config.lastpassFunc)
-	config.addTemplateFunc("lastpassRaw", config.lastpassRawFunc)
+	config.addPasswordManagerTemplateFunc("lastpass", config.lastpassFunc)
+	config.addSecretTemplateFunc("lastpassRaw", config.lastpassRawFunc)
 
 	secretCmd.AddCommand(lastpassCmd)
 }
@@ -68,17 +66,20 @@ func (c *Config) lastpassRawFunc(id string) []map[string]interface{} {
 	c.Lastpass.versionCheckOnce.Do(func() {
 		panicOnError(c.lastpassVersionCheck())
 	})
-	if data, ok := lastPassCache[id]; ok {
-		return data
-	}
-	output, err := c.lastpassOutput("show", "--json", id)
+	data, err := c.getSecret(&cmdSecretProvider{
+		name:    "lastpass",
+		command: c.Lastpass.Command,
+		args:    []string{"show", "--json"},
+		parseOutput: func(output []byte) (interface{}, error) {
+			var data []map[string]interface{}
+			if err := json.Unmarshal(output, &data); err != nil {
+				return nil, err
+			}
+			return data, nil
+		},
+	}, []string{id})
 	panicOnError(err)
-	var data []map[string]interface{}
-	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("parse error: %w\n%q", err, output))
-	}
-	lastPassCache[id] = data
-	return data
+	return data.([]map[string]interface{})
 }
 
 func (c *Config) lastpassFunc(id string) []map[string]interface{} {
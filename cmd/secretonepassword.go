@@ -3,13 +3,8 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
-	"strings"
 
 	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 var onepasswordCmd = &cobra.Command{
@@ -23,13 +18,11 @@ type onepasswordCmdConfig struct {
 	Command string
 }
 
-var onepasswordOutputCache = make(map[string][]byte)
-
 func init() {
 	config.Onepassword.Command = "op"
-	config.addTemplateFunc("onepassword", config.onepasswordFunc)
-	config.addTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
-	config.addTemplateFunc("onepasswordDetailsFields", config.onepasswordDetailsFieldsFunc)
+	config.addPasswordManagerTemplateFunc("onepassword", config.onepasswordFunc)
+	config.addSecretTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
+	config.addPasswordManagerTemplateFunc("onepasswordDetailsFields", config.onepasswordDetailsFieldsFunc)
 
 	secretCmd.AddCommand(onepasswordCmd)
 }
@@ -38,71 +31,63 @@ func (c *Config) runOnepasswordCmd(cmd *cobra.Command, args []string) error {
 	return c.run("", c.Onepassword.Command, args...)
 }
 
-func (c *Config) onepasswordOutput(args []string) []byte {
-	key := strings.Join(args, "\x00")
-	if output, ok := onepasswordOutputCache[key]; ok {
-		return output
-	}
-
-	name := c.Onepassword.Command
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-
-	onepasswordOutputCache[key] = output
-	return output
+func (c *Config) onepasswordSecret(args []string, parseOutput func([]byte) (interface{}, error)) interface{} {
+	value, err := c.getSecret(&cmdSecretProvider{
+		name:        "onepassword",
+		command:     c.Onepassword.Command,
+		parseOutput: parseOutput,
+	}, args)
+	panicOnError(err)
+	return value
 }
 
 func (c *Config) onepasswordFunc(args ...string) map[string]interface{} {
 	key, vault := onepasswordGetKeyAndVault(args)
-	onepasswordArgs := []string{"get", "item", key}
-	if vault != "" {
-		onepasswordArgs = append(onepasswordArgs, "--vault", vault)
-	}
-	output := c.onepasswordOutput(onepasswordArgs)
-	var data map[string]interface{}
-	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", c.Onepassword.Command, chezmoi.ShellQuoteArgs(onepasswordArgs), err, output))
-	}
-	return data
+	return c.onepasswordSecret(onepasswordArgs("item", key, vault), func(output []byte) (interface{}, error) {
+		var data map[string]interface{}
+		if err := json.Unmarshal(output, &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}).(map[string]interface{})
 }
 
 func (c *Config) onepasswordDocumentFunc(args ...string) string {
 	key, vault := onepasswordGetKeyAndVault(args)
-	onepasswordArgs := []string{"get", "document", key}
-	if vault != "" {
-		onepasswordArgs = append(onepasswordArgs, "--vault", vault)
-	}
-	output := c.onepasswordOutput(onepasswordArgs)
-	return string(output)
+	return c.onepasswordSecret(onepasswordArgs("document", key, vault), func(output []byte) (interface{}, error) {
+		return string(output), nil
+	}).(string)
 }
 
 func (c *Config) onepasswordDetailsFieldsFunc(args ...string) map[string]interface{} {
 	key, vault := onepasswordGetKeyAndVault(args)
-	onepasswordArgs := []string{"get", "item", key}
-	if vault != "" {
-		onepasswordArgs = append(onepasswordArgs, "--vault", vault)
-	}
-	output := c.onepasswordOutput(onepasswordArgs)
-	var data struct {
-		Details struct {
-			Fields []map[string]interface{} `json:"fields"`
-		} `json:"details"`
-	}
-	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", c.Onepassword.Command, chezmoi.ShellQuoteArgs(onepasswordArgs), err, output))
-	}
-	result := make(map[string]interface{})
-	for _, field := range data.Details.Fields {
-		if designation, ok := field["designation"].(string); ok {
-			result[designation] = field
+	return c.onepasswordSecret(onepasswordArgs("item", key, vault), func(output []byte) (interface{}, error) {
+		var data struct {
+			Details struct {
+				Fields []map[string]interface{} `json:"fields"`
+			} `json:"details"`
 		}
+		if err := json.Unmarshal(output, &data); err != nil {
+			return nil, err
+		}
+		result := make(map[string]interface{})
+		for _, field := range data.Details.Fields {
+			if designation, ok := field["designation"].(string); ok {
+				result[designation] = field
+			}
+		}
+		return result, nil
+	}).(map[string]interface{})
+}
+
+// onepasswordArgs returns the arguments to op to get the object of type
+// objectType with key from vault.
+func onepasswordArgs(objectType, key, vault string) []string {
+	args := []string{"get", objectType, key}
+	if vault != "" {
+		args = append(args, "--vault", vault)
 	}
-	return result
+	return args
 }
 
 func onepasswordGetKeyAndVault(args []string) (string, string) {
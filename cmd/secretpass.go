@@ -1,15 +1,6 @@
 package cmd
 
-import (
-	"bytes"
-	"fmt"
-	"os"
-	"os/exec"
-
-	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
-)
+import "github.com/spf13/cobra"
 
 var passCmd = &cobra.Command{
 	Use:     "pass [args...]",
@@ -22,13 +13,11 @@ type passCmdConfig struct {
 	Command string
 }
 
-var passCache = make(map[string]string)
-
 func init() {
 	secretCmd.AddCommand(passCmd)
 
 	config.Pass.Command = "pass"
-	config.addTemplateFunc("pass", config.passFunc)
+	config.addSecretTemplateFunc("pass", config.passFunc)
 }
 
 func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
@@ -36,24 +25,12 @@ func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) passFunc(id string) string {
-	if s, ok := passCache[id]; ok {
-		return s
-	}
-	name := c.Pass.Command
-	args := []string{"show", id}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
-	}
-	var password string
-	if index := bytes.IndexByte(output, '\n'); index != -1 {
-		password = string(output[:index])
-	} else {
-		password = string(output)
-	}
-	passCache[id] = password
-	return passCache[id]
+	password, err := c.getSecret(&cmdSecretProvider{
+		name:        "pass",
+		command:     c.Pass.Command,
+		args:        []string{"show"},
+		parseOutput: parseFirstLineSecretOutput,
+	}, []string{id})
+	panicOnError(err)
+	return password.(string)
 }
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// A SecretProvider retrieves secrets by running a secret manager's command.
+type SecretProvider interface {
+	// Name returns the provider's name. It is used as the provider's key in
+	// the secretCache.ttl configuration variable.
+	Name() string
+	// Cmd returns the command that retrieves the secret identified by args.
+	Cmd(args []string) (*exec.Cmd, error)
+	// ParseOutput returns the secret's value from the command's output.
+	ParseOutput(output []byte) (interface{}, error)
+}
+
+// A cmdSecretProvider is a SecretProvider that runs command with args followed
+// by the secret's args.
+type cmdSecretProvider struct {
+	name        string
+	command     string
+	args        []string
+	parseOutput func([]byte) (interface{}, error)
+}
+
+// A customSecretProviderConfig is a secret provider declared in the config
+// file.
+type customSecretProviderConfig struct {
+	Command  string
+	Args     []string
+	Format   string
+	JSONPath string
+}
+
+// A customSecretProvider is a SecretProvider declared in the config file.
+type customSecretProvider struct {
+	name          string
+	command       string
+	argsTemplates []*template.Template
+	parseOutput   func([]byte) (interface{}, error)
+	jsonPath      []string
+}
+
+// getSecret returns the value of the secret identified by args from provider.
+// Only one secret provider command runs at a time, even when templates are
+// executed concurrently, as the commands may prompt on the terminal, for
+// example to unlock the secret manager.
+func (c *Config) getSecret(provider SecretProvider, args []string) (interface{}, error) {
+	cmd, err := provider.Cmd(args)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.secretOutput(provider.Name(), cmd.Args, func() ([]byte, error) {
+		c.secretCmdMutex.Lock()
+		defer c.secretCmdMutex.Unlock()
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
+	// The command's output is not included in errors as it may contain
+	// secrets.
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", chezmoi.ShellQuoteArgs(cmd.Args), err)
+	}
+	value, err := provider.ParseOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", chezmoi.ShellQuoteArgs(cmd.Args), err)
+	}
+	return value, nil
+}
+
+// Name implements SecretProvider.Name.
+func (p *cmdSecretProvider) Name() string {
+	return p.name
+}
+
+// Cmd implements SecretProvider.Cmd.
+func (p *cmdSecretProvider) Cmd(args []string) (*exec.Cmd, error) {
+	//nolint:gosec
+	cmd := exec.Command(p.command, append(append([]string{}, p.args...), args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// ParseOutput implements SecretProvider.ParseOutput.
+func (p *cmdSecretProvider) ParseOutput(output []byte) (interface{}, error) {
+	return p.parseOutput(output)
+}
+
+// addSecretProviderTemplateFuncs adds a template function for each custom
+// secret provider in c.SecretProviders.
+func (c *Config) addSecretProviderTemplateFuncs() error {
+	for name, providerConfig := range c.SecretProviders {
+		if _, ok := c.templateFuncs[name]; ok {
+			return fmt.Errorf("secretProviders.%s: template function already defined", name)
+		}
+		provider, err := newCustomSecretProvider(name, providerConfig, c.templateFuncs)
+		if err != nil {
+			return fmt.Errorf("secretProviders.%s: %w", name, err)
+		}
+		c.addSecretTemplateFunc(name, func(args ...string) interface{} {
+			value, err := c.getSecret(provider, args)
+			panicOnError(err)
+			return value
+		})
+	}
+	return nil
+}
+
+// newCustomSecretProvider returns a new customSecretProvider named name
+// configured by providerConfig. Its args templates can use funcs.
+func newCustomSecretProvider(name string, providerConfig customSecretProviderConfig, funcs template.FuncMap) (*customSecretProvider, error) {
+	if providerConfig.Command == "" {
+		return nil, fmt.Errorf("command not set")
+	}
+	p := &customSecretProvider{
+		name:    name,
+		command: providerConfig.Command,
+	}
+	for i, arg := range providerConfig.Args {
+		argTemplate, err := template.New(strconv.Itoa(i)).Option("missingkey=error").Funcs(funcs).Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		p.argsTemplates = append(p.argsTemplates, argTemplate)
+	}
+	switch strings.ToLower(providerConfig.Format) {
+	case "", "raw":
+		if providerConfig.JSONPath != "" {
+			return nil, fmt.Errorf("jsonPath not supported with raw format")
+		}
+		p.parseOutput = parseRawSecretOutput
+	case "json":
+		p.parseOutput = parseJSONSecretOutput
+	case "keyvalue":
+		p.parseOutput = parseKeyValueSecretOutput
+	default:
+		return nil, fmt.Errorf("%s: unknown format", providerConfig.Format)
+	}
+	if providerConfig.JSONPath != "" {
+		jsonPath := strings.TrimPrefix(strings.TrimPrefix(providerConfig.JSONPath, "$"), ".")
+		p.jsonPath = strings.Split(jsonPath, ".")
+	}
+	return p, nil
+}
+
+// Name implements SecretProvider.Name.
+func (p *customSecretProvider) Name() string {
+	return p.name
+}
+
+// Cmd implements SecretProvider.Cmd. If p has no args templates then args are
+// passed to p's command unchanged. Otherwise, each template is executed with
+// args available as .args to give a single argument.
+func (p *customSecretProvider) Cmd(args []string) (*exec.Cmd, error) {
+	cmdArgs := args
+	if p.argsTemplates != nil {
+		data := map[string]interface{}{
+			"args": args,
+		}
+		cmdArgs = make([]string, 0, len(p.argsTemplates))
+		for _, argTemplate := range p.argsTemplates {
+			sb := &strings.Builder{}
+			if err := argTemplate.Execute(sb, data); err != nil {
+				return nil, fmt.Errorf("%s: %w", p.name, err)
+			}
+			cmdArgs = append(cmdArgs, sb.String())
+		}
+	}
+	//nolint:gosec
+	cmd := exec.Command(p.command, cmdArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// ParseOutput implements SecretProvider.ParseOutput.
+func (p *customSecretProvider) ParseOutput(output []byte) (interface{}, error) {
+	value, err := p.parseOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	if p.jsonPath == nil {
+		return value, nil
+	}
+	return lookupJSONPath(value, p.jsonPath)
+}
+
+// lookupJSONPath returns the value at path in value. Each element of path is
+// either a key in an object or an index in an array.
+func lookupJSONPath(value interface{}, path []string) (interface{}, error) {
+	for i, element := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[element]; !ok {
+				return nil, fmt.Errorf("%s: not found", strings.Join(path[:i+1], "."))
+			}
+		case map[string]string:
+			s, ok := v[element]
+			if !ok {
+				return nil, fmt.Errorf("%s: not found", strings.Join(path[:i+1], "."))
+			}
+			value = s
+		case []interface{}:
+			index, err := strconv.Atoi(element)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("%s: invalid index", strings.Join(path[:i+1], "."))
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("%s: not an object or array", strings.Join(path[:i], "."))
+		}
+	}
+	return value, nil
+}
+
+// parseFirstLineSecretOutput returns the first line of output.
+func parseFirstLineSecretOutput(output []byte) (interface{}, error) {
+	if index := bytes.IndexByte(output, '\n'); index != -1 {
+		return string(output[:index]), nil
+	}
+	return string(output), nil
+}
+
+// parseJSONSecretOutput parses output as JSON.
+func parseJSONSecretOutput(output []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(output, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parseKeyValueSecretOutput parses output as lines of key: value or key=value
+// pairs. Empty lines are ignored.
+func parseKeyValueSecretOutput(output []byte) (interface{}, error) {
+	value := make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		index := strings.IndexAny(line, ":=")
+		if index == -1 {
+			return nil, fmt.Errorf("line %d: cannot parse", lineNumber)
+		}
+		value[strings.TrimSpace(line[:index])] = strings.TrimSpace(line[index+1:])
+	}
+	return value, s.Err()
+}
+
+// parseRawSecretOutput returns output with leading and trailing whitespace
+// removed.
+func parseRawSecretOutput(output []byte) (interface{}, error) {
+	return string(bytes.TrimSpace(output)), nil
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// A concurrencyMutator is a chezmoi.NullMutator that records the maximum
+// number of commands that it runs at the same time.
+type concurrencyMutator struct {
+	chezmoi.NullMutator
+	running    int32
+	maxRunning int32
+}
+
+func (m *concurrencyMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	running := atomic.AddInt32(&m.running, 1)
+	defer atomic.AddInt32(&m.running, -1)
+	for {
+		maxRunning := atomic.LoadInt32(&m.maxRunning)
+		if running <= maxRunning || atomic.CompareAndSwapInt32(&m.maxRunning, maxRunning, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return []byte(cmd.Args[len(cmd.Args)-1]), nil
+}
+
+func TestCustomSecretProviderCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		config       customSecretProviderConfig
+		args         []string
+		expectedArgs []string
+	}{
+		{
+			name: "no_args",
+			config: customSecretProviderConfig{
+				Command: "rbw",
+			},
+			args:         []string{"get", "example.com"},
+			expectedArgs: []string{"rbw", "get", "example.com"},
+		},
+		{
+			name: "args_template",
+			config: customSecretProviderConfig{
+				Command: "doppler",
+				Args:    []string{"secrets", "get", "{{ index .args 0 }}", "--project={{ index .args 1 }}", "--plain"},
+			},
+			args:         []string{"API_KEY", "my project"},
+			expectedArgs: []string{"doppler", "secrets", "get", "API_KEY", "--project=my project", "--plain"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := newCustomSecretProvider(tc.name, tc.config, nil)
+			require.NoError(t, err)
+			cmd, err := p.Cmd(tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedArgs, cmd.Args)
+		})
+	}
+}
+
+func TestCustomSecretProviderParseOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		format        string
+		jsonPath      string
+		output        string
+		expectedValue interface{}
+		expectedErr   bool
+	}{
+		{
+			name:          "raw",
+			output:        "  password\n",
+			expectedValue: "password",
+		},
+		{
+			name:   "json",
+			format: "json",
+			output: `{"password":"secret"}`,
+			expectedValue: map[string]interface{}{
+				"password": "secret",
+			},
+		},
+		{
+			name:          "json_path",
+			format:        "json",
+			jsonPath:      "$.data.items.1.value",
+			output:        `{"data":{"items":[{"value":"a"},{"value":"b"}]}}`,
+			expectedValue: "b",
+		},
+		{
+			name:        "json_path_not_found",
+			format:      "json",
+			jsonPath:    "data.missing",
+			output:      `{"data":{}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "json_path_invalid_index",
+			format:      "json",
+			jsonPath:    "2",
+			output:      `["a","b"]`,
+			expectedErr: true,
+		},
+		{
+			name:   "key_value",
+			format: "keyValue",
+			output: "username: user\npassword=pass:word\n\nurl: https://example.com\n",
+			expectedValue: map[string]string{
+				"username": "user",
+				"password": "pass:word",
+				"url":      "https://example.com",
+			},
+		},
+		{
+			name:          "key_value_json_path",
+			format:        "keyValue",
+			jsonPath:      "password",
+			output:        "username: user\npassword: secret\n",
+			expectedValue: "secret",
+		},
+		{
+			name:        "key_value_invalid",
+			format:      "keyValue",
+			output:      "invalid\n",
+			expectedErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := newCustomSecretProvider(tc.name, customSecretProviderConfig{
+				Command:  "secret",
+				Format:   tc.format,
+				JSONPath: tc.jsonPath,
+			}, nil)
+			require.NoError(t, err)
+			value, err := p.ParseOutput([]byte(tc.output))
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedValue, value)
+			}
+		})
+	}
+}
+
+func TestNewCustomSecretProviderErrors(t *testing.T) {
+	for name, providerConfig := range map[string]customSecretProviderConfig{
+		"no_command":       {},
+		"invalid_template": {Command: "secret", Args: []string{"{{"}},
+		"unknown_format":   {Command: "secret", Format: "xml"},
+		"raw_json_path":    {Command: "secret", JSONPath: "password"},
+	} {
+		_, err := newCustomSecretProvider(name, providerConfig, nil)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestGetSecretSerialized(t *testing.T) {
+	mutator := &concurrencyMutator{}
+	c := newConfig(withMutator(mutator))
+	provider := &cmdSecretProvider{
+		name:        "test",
+		command:     "test",
+		parseOutput: parseRawSecretOutput,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(arg string) {
+			defer wg.Done()
+			value, err := c.getSecret(provider, []string{arg})
+			assert.NoError(t, err)
+			assert.Equal(t, arg, value)
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), mutator.maxRunning)
+}
+
+func TestAddSecretProviderTemplateFuncs(t *testing.T) {
+	c := newConfig()
+	c.SecretProviders = map[string]customSecretProviderConfig{
+		"env": {Command: "secret"},
+	}
+	assert.Error(t, c.addSecretProviderTemplateFuncs())
+
+	c = newConfig()
+	c.SecretProviders = map[string]customSecretProviderConfig{
+		"rbw": {Command: "rbw"},
+	}
+	require.NoError(t, c.addSecretProviderTemplateFuncs())
+	assert.Contains(t, c.templateFuncs, "rbw")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var sopsCmd = &cobra.Command{
+	Use:     "sops [args...]",
+	Short:   "Execute the sops CLI",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSecretSopsCmd,
+}
+
+type sopsCmdConfig struct {
+	Command string
+	Args    []string
+}
+
+func init() {
+	secretCmd.AddCommand(sopsCmd)
+
+	config.Sops.Command = "sops"
+	config.addSecretTemplateFunc("sops", config.sopsFunc)
+}
+
+func (c *Config) runSecretSopsCmd(cmd *cobra.Command, args []string) error {
+	return c.run("", c.Sops.Command, args...)
+}
+
+// sopsDecrypt returns the decrypted contents of the sops-encrypted file at
+// path. sops determines the file's format from its extension.
+func (c *Config) sopsDecrypt(path string) (interface{}, error) {
+	args := append(append([]string{}, c.Sops.Args...), "--decrypt", "--output-type", "json")
+	return c.getSecret(&cmdSecretProvider{
+		name:        "sops",
+		command:     c.Sops.Command,
+		args:        args,
+		parseOutput: parseJSONSecretOutput,
+	}, []string{path})
+}
+
+func (c *Config) sopsFunc(path string) interface{} {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.SourceDir, path)
+	}
+	value, err := c.sopsDecrypt(path)
+	panicOnError(err)
+	return value
+}
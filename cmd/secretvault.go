@@ -1,15 +1,6 @@
 package cmd
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/exec"
-
-	"github.com/spf13/cobra"
-
-	"github.com/twpayne/chezmoi/internal/chezmoi"
-)
+import "github.com/spf13/cobra"
 
 var vaultCmd = &cobra.Command{
 	Use:     "vault [args...]",
@@ -22,11 +13,9 @@ type vaultCmdConfig struct {
 	Command string
 }
 
-var vaultCache = make(map[string]interface{})
-
 func init() {
 	config.Vault.Command = "vault"
-	config.addTemplateFunc("vault", config.vaultFunc)
+	config.addSecretTemplateFunc("vault", config.vaultFunc)
 
 	secretCmd.AddCommand(vaultCmd)
 }
@@ -36,22 +25,12 @@ func (c *Config) runVaultCmd(cmd *cobra.Command, args []string) error {
 }
 
 func (c *Config) vaultFunc(key string) interface{} {
-	if data, ok := vaultCache[key]; ok {
-		return data
-	}
-	name := c.Vault.Command
-	args := []string{"kv", "get", "-format=json", key}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-	var data interface{}
-	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("%s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
-	}
-	vaultCache[key] = data
+	data, err := c.getSecret(&cmdSecretProvider{
+		name:        "vault",
+		command:     c.Vault.Command,
+		args:        []string{"kv", "get", "-format=json"},
+		parseOutput: parseJSONSecretOutput,
+	}, []string{key})
+	panicOnError(err)
 	return data
 }
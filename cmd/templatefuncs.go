@@ -1,17 +1,65 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 func init() {
+	config.addTemplateFunc("fromIni", config.fromIniFunc)
+	config.addTemplateFunc("fromJson", config.fromJSONFunc)
+	config.addTemplateFunc("fromToml", config.fromTOMLFunc)
+	config.addTemplateFunc("fromYaml", config.fromYAMLFunc)
 	config.addTemplateFunc("include", config.includeFunc)
+	config.addTemplateFunc("includeTemplate", config.includeTemplateFunc)
 	config.addTemplateFunc("joinPath", config.joinPathFunc)
 	config.addTemplateFunc("lookPath", config.lookPathFunc)
+	config.addTemplateFunc("output", config.outputFunc)
 	config.addTemplateFunc("stat", config.statFunc)
+	config.addTemplateFunc("toIni", config.toIniFunc)
+	config.addTemplateFunc("toToml", config.toTOMLFunc)
+	config.addTemplateFunc("toYaml", config.toYAMLFunc)
+}
+
+func (c *Config) fromIniFunc(text string) map[string]interface{} {
+	file, err := ini.Load([]byte(text))
+	panicOnError(err)
+	value := make(map[string]interface{})
+	for _, section := range file.Sections() {
+		sectionValue := value
+		if name := section.Name(); name != ini.DefaultSection {
+			sectionValue = make(map[string]interface{})
+			value[name] = sectionValue
+		}
+		for _, key := range section.Keys() {
+			sectionValue[key.Name()] = key.Value()
+		}
+	}
+	return value
+}
+
+func (c *Config) fromJSONFunc(text string) interface{} {
+	return mustUnmarshal("json", text)
+}
+
+func (c *Config) fromTOMLFunc(text string) interface{} {
+	return mustUnmarshal("toml", text)
+}
+
+func (c *Config) fromYAMLFunc(text string) interface{} {
+	return mustUnmarshal("yaml", text)
 }
 
 func (c *Config) includeFunc(filename string) string {
@@ -22,6 +70,12 @@ func (c *Config) includeFunc(filename string) string {
 	return string(contents)
 }
 
+func (c *Config) includeTemplateFunc(name string, data interface{}) string {
+	output, err := c.includeTemplate(name, data)
+	panicOnError(err)
+	return output
+}
+
 func (c *Config) joinPathFunc(elem ...string) string {
 	return filepath.Join(elem...)
 }
@@ -38,6 +92,33 @@ func (c *Config) lookPathFunc(file string) string {
 	}
 }
 
+func (c *Config) toIniFunc(data map[string]interface{}) string {
+	file := ini.Empty()
+	panicOnError(addIniSection(file, ini.DefaultSection, data))
+	sb := &strings.Builder{}
+	_, err := file.WriteTo(sb)
+	panicOnError(err)
+	// ini.File.WriteTo writes a blank line after every section.
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (c *Config) toTOMLFunc(data interface{}) string {
+	return mustFormat("toml", data)
+}
+
+func (c *Config) toYAMLFunc(data interface{}) string {
+	return mustFormat("yaml", data)
+}
+
+func (c *Config) outputFunc(name string, args ...string) string {
+	key := strings.Join(append([]string{name}, args...), "\x00")
+	output, err := c.outputCache.get(key, func() (interface{}, error) {
+		return c.cmdOutput(name, args)
+	})
+	panicOnError(err)
+	return string(output.([]byte))
+}
+
 func (c *Config) statFunc(name string) interface{} {
 	info, err := c.fs.Stat(name)
 	switch {
@@ -56,3 +137,123 @@ func (c *Config) statFunc(name string) interface{} {
 		panic(err)
 	}
 }
+
+// addIniSection adds the values in data to the section called name in file.
+// Maps in data are added as child sections.
+func addIniSection(file *ini.File, name string, data map[string]interface{}) error {
+	var keys, childKeys []string
+	for key, value := range data {
+		if _, ok := value.(map[string]interface{}); ok {
+			childKeys = append(childKeys, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	sort.Strings(childKeys)
+	// Only add sections without keys if they do not have child sections.
+	if len(keys) != 0 || len(childKeys) == 0 {
+		section, err := file.NewSection(name)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _, err := section.NewKey(key, fmt.Sprint(data[key])); err != nil {
+				return err
+			}
+		}
+	}
+	for _, key := range childKeys {
+		childName := key
+		if name != ini.DefaultSection {
+			childName = name + "." + key
+		}
+		if err := addIniSection(file, childName, data[key].(map[string]interface{})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mustFormat returns value formatted in format, panicking on any error.
+func mustFormat(format string, value interface{}) string {
+	sb := &strings.Builder{}
+	panicOnError(formatMap[format](sb, value))
+	return sb.String()
+}
+
+// mustUnmarshal returns text parsed as format, panicking on any error.
+func mustUnmarshal(format, text string) interface{} {
+	value, err := unmarshalFormatMap[format]([]byte(text))
+	panicOnError(err)
+	return value
+}
+
+// cmdOutput returns the output of running name with args, killing it if it
+// runs for longer than c.Output.Timeout. If the command fails then the error
+// includes its standard error.
+func (c *Config) cmdOutput(name string, args []string) ([]byte, error) {
+	ctx := context.Background()
+	if c.Output.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Output.Timeout)
+		defer cancel()
+	}
+	stderr := &bytes.Buffer{}
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s: timed out after %s", chezmoi.ShellQuoteArgs(cmd.Args), c.Output.Timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w\n%s", chezmoi.ShellQuoteArgs(cmd.Args), err, stderr.Bytes())
+	}
+	return output, nil
+}
+
+// includeTemplate returns the result of executing the template name with data.
+// name is looked up first in the .chezmoitemplates directory and then relative
+// to the source directory. All templates in the .chezmoitemplates directory
+// are available to the template.
+func (c *Config) includeTemplate(name string, data interface{}) (string, error) {
+	var templates map[string]*template.Template
+	if c.targetState != nil {
+		templates = c.targetState.Templates
+	}
+
+	// The parsed templates are shared with the target state, so clone them
+	// before adding the other templates' parse trees.
+	tmpl, ok := templates[filepath.ToSlash(name)]
+	if ok {
+		var err error
+		tmpl, err = tmpl.Clone()
+		if err != nil {
+			return "", err
+		}
+	} else {
+		contents, err := c.fs.ReadFile(filepath.Join(c.SourceDir, name))
+		if err != nil {
+			return "", err
+		}
+		tmpl, err = template.New(name).Option(c.Template.Options...).Funcs(c.templateFuncs).Parse(string(contents))
+		if err != nil {
+			return "", err
+		}
+	}
+	for templateName, t := range templates {
+		if templateName == tmpl.Name() {
+			continue
+		}
+		if _, err := tmpl.AddParseTree(templateName, t.Tree); err != nil {
+			return "", err
+		}
+	}
+
+	sb := &strings.Builder{}
+	if err := tmpl.Execute(sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
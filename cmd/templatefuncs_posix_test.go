@@ -0,0 +1,48 @@
+// +build !windows
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+func TestOutputFunc(t *testing.T) {
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	assert.Equal(t, "hello world\n", c.outputFunc("echo", "hello", "world"))
+	assert.Equal(t, "", c.outputFunc("true"))
+}
+
+func TestOutputFuncCache(t *testing.T) {
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+	first := c.outputFunc("date", "+%N")
+	assert.Equal(t, first, c.outputFunc("date", "+%N"))
+}
+
+func TestCmdOutputErrors(t *testing.T) {
+	c := newConfig(
+		withMutator(chezmoi.NullMutator{}),
+	)
+
+	_, err := c.cmdOutput("sh", []string{"-c", "echo error message >&2; exit 1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error message")
+
+	c.Output.Timeout = 10 * time.Millisecond
+	_, err = c.cmdOutput("sleep", []string{"1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+
+	assert.Panics(t, func() {
+		c.outputFunc("false")
+	})
+}
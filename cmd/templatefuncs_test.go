@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFromFuncs(t *testing.T) {
+	c := newConfig()
+	expected := map[string]interface{}{
+		"core": map[string]interface{}{
+			"editor": "vim",
+		},
+	}
+	assert.Equal(t, expected, c.fromIniFunc("[core]\neditor = vim\n"))
+	assert.Equal(t, expected, c.fromJSONFunc(`{"core":{"editor":"vim"}}`))
+	assert.Equal(t, expected, c.fromTOMLFunc("[core]\neditor = \"vim\"\n"))
+	assert.Equal(t, expected, c.fromYAMLFunc("core:\n  editor: vim\n"))
+	assert.Panics(t, func() {
+		c.fromJSONFunc("{")
+	})
+}
+
+func TestToFuncs(t *testing.T) {
+	c := newConfig()
+	data := map[string]interface{}{
+		"core": map[string]interface{}{
+			"editor": "vim",
+		},
+	}
+	assert.Equal(t, "[core]\neditor = vim\n", c.toIniFunc(data))
+	assert.Equal(t, data, c.fromIniFunc(c.toIniFunc(data)))
+	assert.Equal(t, data, c.fromTOMLFunc(c.toTOMLFunc(data)))
+	assert.Equal(t, "core:\n  editor: vim\n", c.toYAMLFunc(data))
+}
+
+func TestToIniFunc(t *testing.T) {
+	c := newConfig()
+	assert.Equal(t, "name = John Smith\n\n[remote.origin]\nurl = https://example.com\n", c.toIniFunc(map[string]interface{}{
+		"name": "John Smith",
+		"remote": map[string]interface{}{
+			"origin": map[string]interface{}{
+				"url": "https://example.com",
+			},
+		},
+	}))
+}
+
+func TestIncludeTemplate(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitemplates/greeting":   "{{ template \"salutation\" }}, {{ . }}!",
+			".chezmoitemplates/salutation": "Hello",
+			"farewell.tmpl":                "Goodbye, {{ . }}!",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+	c := newTestConfig(fs)
+	_, err = c.getTargetState(nil)
+	require.NoError(t, err)
+
+	output, err := c.includeTemplate("greeting", "world")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world!", output)
+
+	output, err = c.includeTemplate("farewell.tmpl", "world")
+	require.NoError(t, err)
+	assert.Equal(t, "Goodbye, world!", output)
+
+	// The templates parsed by the target state are used, and are parsed again
+	// for a new target state.
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/.chezmoitemplates/salutation", []byte("Hi"), 0o666))
+	output, err = c.includeTemplate("greeting", "world")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world!", output)
+	_, err = c.getTargetState(nil)
+	require.NoError(t, err)
+	output, err = c.includeTemplate("greeting", "world")
+	require.NoError(t, err)
+	assert.Equal(t, "Hi, world!", output)
+
+	_, err = c.includeTemplate("missing", nil)
+	assert.Error(t, err)
+}
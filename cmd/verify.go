@@ -19,12 +19,15 @@ var verifyCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(verifyCmd)
 
+	addIncludeExcludeFlags(verifyCmd)
+
 	markRemainingZshCompPositionalArgumentsAsFiles(verifyCmd, 1)
 }
 
 func (c *Config) runVerifyCmd(cmd *cobra.Command, args []string) error {
 	mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 	c.mutator = mutator
+	c.Merge.RecordBase = false // Nothing is applied, so there is nothing to record.
 
 	persistentState, err := c.getPersistentState(&bolt.Options{
 		ReadOnly: true,
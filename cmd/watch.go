@@ -0,0 +1,590 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	vfs "github.com/twpayne/go-vfs"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Args:    cobra.NoArgs,
+	Short:   "Apply changes to the source directory as they are made",
+	Long:    mustGetLongHelp("watch"),
+	Example: getExample("watch"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runWatchCmd,
+}
+
+type watchCmdConfig struct {
+	add          bool
+	allowSecrets bool
+	debounce     time.Duration
+	encrypt      bool
+	merge        bool
+	targets      bool
+	watchConfig  bool
+}
+
+// A sourceWatcher watches the source directory and applies the entries
+// affected by each change.
+type sourceWatcher struct {
+	c             *Config
+	watcher       *fsnotify.Watcher
+	rawSourceDir  string
+	rawConfigFile string
+	targetNames   map[string]string
+}
+
+// A targetWatcher watches the destination paths of managed files and symlinks
+// and reports the targets that are changed outside chezmoi.
+type targetWatcher struct {
+	c             *Config
+	cmd           *cobra.Command
+	watcher       *fsnotify.Watcher
+	secretScanner *chezmoi.SecretScanner
+	rawDestDir    string
+	entries       map[string]chezmoi.Entry
+	watchedDirs   map[string]struct{}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	persistentFlags := watchCmd.PersistentFlags()
+	persistentFlags.BoolVar(&config.watch.add, "add", false, "with --targets, add changed non-template targets to the source state")
+	persistentFlags.BoolVar(&config.watch.allowSecrets, "allow-secrets", false, "with --add, add targets that may contain secrets without encrypting them")
+	persistentFlags.DurationVar(&config.watch.debounce, "debounce", 100*time.Millisecond, "time to wait for further changes before applying")
+	persistentFlags.BoolVar(&config.watch.encrypt, "encrypt", false, "with --add, encrypt added files")
+	persistentFlags.BoolVar(&config.watch.merge, "merge", false, "with --targets, merge changed targets that are not added")
+	persistentFlags.BoolVar(&config.watch.targets, "targets", false, "watch managed targets instead of the source directory")
+	persistentFlags.BoolVar(&config.watch.watchConfig, "watch-config", false, "also watch the config file")
+	addIncludeExcludeFlags(watchCmd)
+}
+
+func (c *Config) runWatchCmd(cmd *cobra.Command, args []string) error {
+	if !c.watch.targets && (c.watch.add || c.watch.merge) {
+		return fmt.Errorf("--add and --merge require --targets")
+	}
+	if !c.watch.add && (c.watch.allowSecrets || c.watch.encrypt) {
+		return fmt.Errorf("--allow-secrets and --encrypt require --add")
+	}
+
+	done := make(chan struct{})
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	defer signal.Stop(signalChan)
+	go func() {
+		<-signalChan
+		close(done)
+	}()
+
+	if c.watch.targets {
+		w, err := c.newTargetWatcher(cmd)
+		if err != nil {
+			return err
+		}
+		defer w.close()
+		fmt.Fprintf(c.Stdout, "watching %d targets in %s\n", len(w.entries), c.DestDir)
+		return w.run(done)
+	}
+
+	w, err := c.newSourceWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	fmt.Fprintf(c.Stdout, "watching %s\n", c.SourceDir)
+	return w.run(done)
+}
+
+// newSourceWatcher returns a new sourceWatcher that is watching the source
+// directory and, if requested, the config file.
+func (c *Config) newSourceWatcher() (*sourceWatcher, error) {
+	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &sourceWatcher{
+		c:            c,
+		watcher:      watcher,
+		rawSourceDir: rawSourceDir,
+	}
+	if err := w.addDir(rawSourceDir); err != nil {
+		w.close()
+		return nil, err
+	}
+	if c.watch.watchConfig {
+		w.rawConfigFile, err = filepath.Abs(c.configFile)
+		if err != nil {
+			w.close()
+			return nil, err
+		}
+		// Watch the config file's directory, rather than the config file
+		// itself, so that the config file continues to be watched when it is
+		// replaced.
+		if err := w.watcher.Add(filepath.Dir(w.rawConfigFile)); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+	if ts, err := c.getTargetState(nil); err == nil {
+		w.targetNames = getTargetNames(ts)
+	}
+	return w, nil
+}
+
+// run applies changes until done is closed.
+func (w *sourceWatcher) run(done <-chan struct{}) error {
+	changedRelPaths := make(map[string]struct{})
+	applyAll := false
+	return w.c.watchLoop(w.watcher, done, func(event fsnotify.Event) bool {
+		if event.Name == w.rawConfigFile {
+			if err := w.c.reloadConfigFile(); err != nil {
+				w.c.printWatchError(err)
+				return false
+			}
+			applyAll = true
+			return true
+		}
+		relPath, err := filepath.Rel(w.rawSourceDir, event.Name)
+		if err != nil || relPath == "." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return false
+		}
+		switch isSpecial, isIgnored := classifySourceRelPath(relPath); {
+		case isIgnored:
+			return false
+		case isSpecial:
+			applyAll = true
+		default:
+			changedRelPaths[filepath.ToSlash(relPath)] = struct{}{}
+		}
+		if event.Op&fsnotify.Create != 0 {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				if err := w.addDir(event.Name); err != nil {
+					w.c.printWatchError(err)
+				}
+			}
+		}
+		return true
+	}, func() error {
+		defer func() {
+			changedRelPaths = make(map[string]struct{})
+			applyAll = false
+		}()
+		return w.apply(changedRelPaths, applyAll)
+	})
+}
+
+// close stops watching.
+func (w *sourceWatcher) close() {
+	_ = w.watcher.Close()
+}
+
+// addDir watches rawDir and all of its subdirectories that can contain source
+// state.
+func (w *sourceWatcher) addDir(rawDir string) error {
+	return filepath.Walk(rawDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if relPath, err := filepath.Rel(w.rawSourceDir, path); err == nil && relPath != "." {
+			if _, isIgnored := classifySourceRelPath(relPath); isIgnored {
+				return filepath.SkipDir
+			}
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// apply applies the entries affected by changes to changedRelPaths, or all
+// entries if applyAll is true. The persistent state is only open while
+// applying, so that other chezmoi commands can use it while watching.
+func (w *sourceWatcher) apply(changedRelPaths map[string]struct{}, applyAll bool) error {
+	c := w.c
+	persistentState, err := c.getPersistentState(nil)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	include, err := chezmoi.NewIncludeSet(c.include, c.exclude)
+	if err != nil {
+		return err
+	}
+	applyOptions := &chezmoi.ApplyOptions{
+		DestDir:           ts.DestDir,
+		DryRun:            c.DryRun,
+		Ignore:            ts.TargetIgnore.Match,
+		Include:           include,
+		PersistentState:   persistentState,
+		Remove:            c.Remove,
+		ScriptStateBucket: c.scriptStateBucket,
+		Stdout:            c.Stdout,
+		Umask:             ts.Umask,
+		Verbose:           c.Verbose,
+	}
+	fs := vfs.NewReadOnlyFS(c.fs)
+
+	prevTargetNames := w.targetNames
+	w.targetNames = getTargetNames(ts)
+	for relPath := range changedRelPaths {
+		if _, ok := w.targetNames[relPath]; ok {
+			continue
+		}
+		if targetName, ok := prevTargetNames[relPath]; ok {
+			fmt.Fprintf(c.Stdout, "%s: removed from source state\n", filepath.Join(ts.DestDir, targetName))
+		}
+	}
+
+	if applyAll {
+		anyMutator := chezmoi.NewAnyMutator(c.mutator)
+		if err := ts.Apply(fs, anyMutator, c.Follow, applyOptions); err != nil {
+			return err
+		}
+		if anyMutator.Mutated() {
+			fmt.Fprintf(c.Stdout, "applied %s\n", ts.DestDir)
+		}
+		return c.recordMergeBases(ts, include, ts.AllEntries())
+	}
+
+	var allEntries []chezmoi.Entry
+	for _, entry := range getAffectedEntries(ts, changedRelPaths) {
+		anyMutator := chezmoi.NewAnyMutator(c.mutator)
+		if err := entry.Apply(fs, anyMutator, c.Follow, applyOptions); err != nil {
+			return err
+		}
+		if anyMutator.Mutated() {
+			fmt.Fprintf(c.Stdout, "applied %s\n", filepath.Join(ts.DestDir, entry.TargetName()))
+		}
+		allEntries = entry.AppendAllEntries(allEntries)
+	}
+	return c.recordMergeBases(ts, include, allEntries)
+}
+
+// printWatchError prints err without stopping watching.
+func (c *Config) printWatchError(err error) {
+	fmt.Fprintf(c.Stderr, "chezmoi: %v\n", err)
+}
+
+// watchLoop calls handleEvent for each event received by watcher and, once no
+// further relevant events have been received for the debounce duration, calls
+// flush. handleEvent returns whether the event is relevant. Errors returned by
+// flush are printed and do not stop the loop, which runs until done is closed.
+func (c *Config) watchLoop(watcher *fsnotify.Watcher, done <-chan struct{}, handleEvent func(fsnotify.Event) bool, flush func() error) error {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-done:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if handleEvent(event) {
+				debounce = time.After(c.watch.debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-debounce:
+			debounce = nil
+			if err := flush(); err != nil {
+				c.printWatchError(err)
+			}
+		}
+	}
+}
+
+// newTargetWatcher returns a new targetWatcher that is watching all managed
+// files and symlinks.
+func (c *Config) newTargetWatcher(cmd *cobra.Command) (*targetWatcher, error) {
+	rawDestDir, err := c.fs.RawPath(c.DestDir)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return nil, err
+	}
+	var secretScanner *chezmoi.SecretScanner
+	if c.watch.add && !c.watch.allowSecrets && !c.watch.encrypt {
+		secretScanner, err = c.getSecretScanner()
+		if err != nil {
+			return nil, err
+		}
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &targetWatcher{
+		c:             c,
+		cmd:           cmd,
+		watcher:       watcher,
+		secretScanner: secretScanner,
+		rawDestDir:    rawDestDir,
+		watchedDirs:   make(map[string]struct{}),
+	}
+	if err := w.update(ts); err != nil {
+		w.close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// run reports changed targets until done is closed.
+func (w *targetWatcher) run(done <-chan struct{}) error {
+	changedTargetNames := make(map[string]struct{})
+	return w.c.watchLoop(w.watcher, done, func(event fsnotify.Event) bool {
+		targetName, err := filepath.Rel(w.rawDestDir, event.Name)
+		if err != nil {
+			return false
+		}
+		if _, ok := w.entries[targetName]; !ok {
+			return false
+		}
+		changedTargetNames[targetName] = struct{}{}
+		return true
+	}, func() error {
+		defer func() {
+			changedTargetNames = make(map[string]struct{})
+		}()
+		return w.check(changedTargetNames)
+	})
+}
+
+// close stops watching.
+func (w *targetWatcher) close() {
+	_ = w.watcher.Close()
+}
+
+// check reports each target in changedTargetNames that differs from its
+// target state and, if requested, adds it to the source state or merges it.
+func (w *targetWatcher) check(changedTargetNames map[string]struct{}) error {
+	c := w.c
+	include, err := chezmoi.NewIncludeSet(c.include, c.exclude)
+	if err != nil {
+		return err
+	}
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	if err := w.update(ts); err != nil {
+		return err
+	}
+
+	sortedTargetNames := make([]string, 0, len(changedTargetNames))
+	for targetName := range changedTargetNames {
+		sortedTargetNames = append(sortedTargetNames, targetName)
+	}
+	sort.Strings(sortedTargetNames)
+
+	var tempDir string
+	for _, targetName := range sortedTargetNames {
+		entry, ok := w.entries[targetName]
+		if !ok {
+			continue
+		}
+		targetPath := filepath.Join(ts.DestDir, targetName)
+
+		// Print the difference between the destination state and the target
+		// state, if any.
+		sb := &strings.Builder{}
+		anyMutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
+		mutator := chezmoi.NewVerboseMutator(sb, anyMutator, c.colored, c.maxDiffDataSize, c.getRedactor())
+		if err := entry.Apply(vfs.NewReadOnlyFS(c.fs), mutator, c.Follow, &chezmoi.ApplyOptions{
+			DestDir: ts.DestDir,
+			DryRun:  true,
+			Ignore:  ts.TargetIgnore.Match,
+			Include: include,
+			Umask:   ts.Umask,
+		}); err != nil {
+			c.printWatchError(err)
+			continue
+		}
+		if !anyMutator.Mutated() {
+			continue
+		}
+		fmt.Fprintf(c.Stdout, "%s: changed outside chezmoi\n", targetPath)
+		fmt.Fprint(c.Stdout, sb.String())
+
+		var isFile, isTemplate bool
+		switch entry := entry.(type) {
+		case *chezmoi.File:
+			isFile, isTemplate = true, entry.Template
+		case *chezmoi.Symlink:
+			isTemplate = entry.Template
+		}
+		switch {
+		case c.watch.add && !isTemplate:
+			if err := w.add(ts, entry, targetPath); err != nil {
+				c.printWatchError(err)
+				continue
+			}
+			fmt.Fprintf(c.Stdout, "added %s\n", targetPath)
+		case c.watch.merge && isFile:
+			if tempDir == "" {
+				tempDir, err = ioutil.TempDir("", "chezmoi")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tempDir)
+			}
+			if err := c.runMergeCommand(w.cmd, ts, targetPath, entry, tempDir); err != nil {
+				c.printWatchError(err)
+			}
+		case c.watch.add && isFile:
+			fmt.Fprintf(c.Stdout, "%s: not added because its source is a template, run chezmoi merge %s\n", targetPath, chezmoi.MaybeShellQuote(targetPath))
+		case c.watch.add:
+			fmt.Fprintf(c.Stdout, "%s: not added because its source is a template, run chezmoi edit %s\n", targetPath, chezmoi.MaybeShellQuote(targetPath))
+		}
+	}
+	return nil
+}
+
+// add adds the target at targetPath, which corresponds to entry, to the source
+// state, preserving entry's attributes. Files that are not encrypted are
+// checked for secrets first, as for add.
+func (w *targetWatcher) add(ts *chezmoi.TargetState, entry chezmoi.Entry, targetPath string) error {
+	var addOptions chezmoi.AddOptions
+	if file, ok := entry.(*chezmoi.File); ok {
+		addOptions.Empty = file.Empty
+		addOptions.Encrypt = file.Encrypted || w.c.watch.encrypt
+	}
+	if !addOptions.Encrypt {
+		if err := w.c.checkSecrets(w.secretScanner, ts, targetPath, nil); err != nil {
+			return err
+		}
+	}
+	if err := ts.Add(w.c.fs, addOptions, targetPath, nil, false, w.c.mutator); err != nil {
+		return err
+	}
+	return w.c.setMergeBase(entry.TargetName(), nil)
+}
+
+// update records the managed files and symlinks in ts and watches their
+// parent directories.
+func (w *targetWatcher) update(ts *chezmoi.TargetState) error {
+	w.entries = make(map[string]chezmoi.Entry)
+	for _, entry := range ts.AllEntries() {
+		switch entry.(type) {
+		case *chezmoi.File, *chezmoi.Symlink:
+		default:
+			continue
+		}
+		targetName := entry.TargetName()
+		if ts.TargetIgnore.Match(targetName) {
+			continue
+		}
+		w.entries[targetName] = entry
+		// Watch the parent directory, rather than the target itself, so that
+		// the target continues to be watched when it is replaced, as many
+		// editors do.
+		rawDir := filepath.Dir(filepath.Join(w.rawDestDir, targetName))
+		if _, ok := w.watchedDirs[rawDir]; ok {
+			continue
+		}
+		if err := w.watcher.Add(rawDir); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		w.watchedDirs[rawDir] = struct{}{}
+	}
+	return nil
+}
+
+// reloadConfigFile reads the config file again.
+func (c *Config) reloadConfigFile() error {
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	c.Data = nil
+	if err := viper.Unmarshal(c); err != nil {
+		return err
+	}
+	return c.validateData()
+}
+
+// classifySourceRelPath returns whether relPath, relative to the source
+// directory, is a special file that can affect every entry, like
+// .chezmoiignore, or is ignored when reading the source state.
+func classifySourceRelPath(relPath string) (isSpecial, isIgnored bool) {
+	for _, component := range strings.Split(filepath.ToSlash(relPath), "/") {
+		switch {
+		case strings.HasPrefix(component, ".chezmoi"):
+			return true, false
+		case strings.HasPrefix(component, "."):
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// getAffectedEntries returns the entries in ts that are affected by changes to
+// changedRelPaths, sorted by source name. Entries in affected directories are
+// not returned as they are applied with their directories.
+func getAffectedEntries(ts *chezmoi.TargetState, changedRelPaths map[string]struct{}) []chezmoi.Entry {
+	allEntries := ts.AllEntries()
+	sort.Slice(allEntries, func(i, j int) bool {
+		return allEntries[i].SourceName() < allEntries[j].SourceName()
+	})
+	var affectedEntries []chezmoi.Entry
+	var affectedDirSourceNames []string
+FOR:
+	for _, entry := range allEntries {
+		sourceName := filepath.ToSlash(entry.SourceName())
+		for _, dirSourceName := range affectedDirSourceNames {
+			if strings.HasPrefix(sourceName, dirSourceName+"/") {
+				continue FOR
+			}
+		}
+		if _, ok := changedRelPaths[sourceName]; !ok {
+			continue
+		}
+		affectedEntries = append(affectedEntries, entry)
+		if _, ok := entry.(*chezmoi.Dir); ok {
+			affectedDirSourceNames = append(affectedDirSourceNames, sourceName)
+		}
+	}
+	return affectedEntries
+}
+
+// getTargetNames returns a map of all source names in ts to their target
+// names.
+func getTargetNames(ts *chezmoi.TargetState) map[string]string {
+	targetNames := make(map[string]string)
+	for _, entry := range ts.AllEntries() {
+		targetNames[filepath.ToSlash(entry.SourceName())] = entry.TargetName()
+	}
+	return targetNames
+}
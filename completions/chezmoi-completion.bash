@@ -336,6 +336,7 @@ _chezmoi_add()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--allow-secrets")
     flags+=("--autotemplate")
     flags+=("-a")
     flags+=("--empty")
@@ -367,7 +368,14 @@ _chezmoi_add()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -393,6 +401,13 @@ _chezmoi_apply()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--interactive")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -409,7 +424,14 @@ _chezmoi_apply()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -435,6 +457,12 @@ _chezmoi_archive()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--output=")
     two_word_flags+=("--output")
     flags_with_completion+=("--output")
@@ -442,6 +470,212 @@ _chezmoi_archive()
     two_word_flags+=("-o")
     flags_with_completion+=("-o")
     flags_completion+=("_filedir")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    flags+=("--set=")
+    two_word_flags+=("--set")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_backups_list()
+{
+    last_command="chezmoi_backups_list"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_backups_prune()
+{
+    last_command="chezmoi_backups_prune"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--keep=")
+    two_word_flags+=("--keep")
+    local_nonpersistent_flags+=("--keep=")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_backups_restore()
+{
+    last_command="chezmoi_backups_restore"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_backups()
+{
+    last_command="chezmoi_backups"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("list")
+    commands+=("prune")
+    commands+=("restore")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -458,7 +692,14 @@ _chezmoi_archive()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -484,6 +725,11 @@ _chezmoi_cat()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    flags+=("--set=")
+    two_word_flags+=("--set")
+    flags+=("--trace-templates")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -500,7 +746,14 @@ _chezmoi_cat()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -542,7 +795,14 @@ _chezmoi_cd()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -584,7 +844,14 @@ _chezmoi_chattr()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -636,7 +903,14 @@ _chezmoi_completion()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -668,6 +942,10 @@ _chezmoi_data()
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    flags+=("--set=")
+    two_word_flags+=("--set")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -684,7 +962,14 @@ _chezmoi_data()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -710,10 +995,21 @@ _chezmoi_diff()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
     flags+=("--no-pager")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
+    flags+=("--set=")
+    two_word_flags+=("--set")
+    flags+=("--trace-templates")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -730,7 +1026,14 @@ _chezmoi_diff()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -772,7 +1075,14 @@ _chezmoi_docs()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -814,7 +1124,14 @@ _chezmoi_doctor()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -840,11 +1157,21 @@ _chezmoi_dump()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--format=")
     two_word_flags+=("--format")
     two_word_flags+=("-f")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
     flags+=("--recursive")
     flags+=("-r")
+    flags+=("--set=")
+    two_word_flags+=("--set")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -861,7 +1188,14 @@ _chezmoi_dump()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -909,7 +1243,14 @@ _chezmoi_edit()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -951,7 +1292,14 @@ _chezmoi_edit-config()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -982,9 +1330,14 @@ _chezmoi_execute-template()
     flags+=("--output=")
     two_word_flags+=("--output")
     two_word_flags+=("-o")
+    flags+=("--profile=")
+    two_word_flags+=("--profile")
     flags+=("--promptString=")
     two_word_flags+=("--promptString")
     two_word_flags+=("-p")
+    flags+=("--set=")
+    two_word_flags+=("--set")
+    flags+=("--trace-templates")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1001,7 +1354,14 @@ _chezmoi_execute-template()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1043,7 +1403,14 @@ _chezmoi_forget()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1085,7 +1452,14 @@ _chezmoi_git()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1127,7 +1501,14 @@ _chezmoi_hg()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1175,7 +1556,14 @@ _chezmoi_import()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1218,7 +1606,65 @@ _chezmoi_init()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_lint()
+{
+    last_command="chezmoi_lint"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--data=")
+    two_word_flags+=("--data")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1244,6 +1690,9 @@ _chezmoi_managed()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
     flags+=("--include=")
     two_word_flags+=("--include")
     two_word_flags+=("-i")
@@ -1263,7 +1712,14 @@ _chezmoi_managed()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1289,6 +1745,9 @@ _chezmoi_merge()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--autotemplate")
+    flags+=("--strategy=")
+    two_word_flags+=("--strategy")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -1305,7 +1764,66 @@ _chezmoi_merge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_merge-all()
+{
+    last_command="chezmoi_merge-all"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--autotemplate")
+    flags+=("--strategy=")
+    two_word_flags+=("--strategy")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1349,7 +1867,14 @@ _chezmoi_purge()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1393,7 +1918,14 @@ _chezmoi_remove()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1435,7 +1967,113 @@ _chezmoi_secret_bitwarden()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_cache_clear()
+{
+    last_command="chezmoi_secret_cache_clear"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_cache()
+{
+    last_command="chezmoi_secret_cache"
+
+    command_aliases=()
+
+    commands=()
+    commands+=("clear")
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1477,7 +2115,14 @@ _chezmoi_secret_generic()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1519,7 +2164,14 @@ _chezmoi_secret_gopass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1561,7 +2213,14 @@ _chezmoi_secret_keepassxc()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1603,7 +2262,14 @@ _chezmoi_secret_lastpass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1645,7 +2311,14 @@ _chezmoi_secret_onepassword()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1687,7 +2360,63 @@ _chezmoi_secret_pass()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_secret_sops()
+{
+    last_command="chezmoi_secret_sops"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1729,7 +2458,14 @@ _chezmoi_secret_vault()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1749,12 +2485,14 @@ _chezmoi_secret()
 
     commands=()
     commands+=("bitwarden")
+    commands+=("cache")
     commands+=("generic")
     commands+=("gopass")
     commands+=("keepassxc")
     commands+=("lastpass")
     commands+=("onepassword")
     commands+=("pass")
+    commands+=("sops")
     commands+=("vault")
 
     flags=()
@@ -1779,7 +2517,14 @@ _chezmoi_secret()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1821,7 +2566,14 @@ _chezmoi_source()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1863,7 +2615,14 @@ _chezmoi_source-path()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1905,7 +2664,14 @@ _chezmoi_unmanaged()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -1949,7 +2715,14 @@ _chezmoi_update()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -2002,7 +2775,14 @@ _chezmoi_upgrade()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -2028,6 +2808,75 @@ _chezmoi_verify()
     flags_with_completion=()
     flags_completion=()
 
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--color=")
+    two_word_flags+=("--color")
+    flags+=("--config=")
+    two_word_flags+=("--config")
+    flags_with_completion+=("--config")
+    flags_completion+=("_filedir")
+    two_word_flags+=("-c")
+    flags_with_completion+=("-c")
+    flags_completion+=("_filedir")
+    flags+=("--debug")
+    flags+=("--destination=")
+    two_word_flags+=("--destination")
+    two_word_flags+=("-D")
+    flags+=("--dry-run")
+    flags+=("-n")
+    flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
+    flags+=("--remove")
+    flags+=("--show-secrets")
+    flags+=("--source=")
+    two_word_flags+=("--source")
+    two_word_flags+=("-S")
+    flags+=("--verbose")
+    flags+=("-v")
+
+    must_have_one_flag=()
+    must_have_one_noun=()
+    noun_aliases=()
+}
+
+_chezmoi_watch()
+{
+    last_command="chezmoi_watch"
+
+    command_aliases=()
+
+    commands=()
+
+    flags=()
+    two_word_flags=()
+    local_nonpersistent_flags=()
+    flags_with_completion=()
+    flags_completion=()
+
+    flags+=("--add")
+    flags+=("--allow-secrets")
+    flags+=("--debounce=")
+    two_word_flags+=("--debounce")
+    flags+=("--encrypt")
+    flags+=("--exclude=")
+    two_word_flags+=("--exclude")
+    two_word_flags+=("-x")
+    flags+=("--include=")
+    two_word_flags+=("--include")
+    two_word_flags+=("-i")
+    flags+=("--merge")
+    flags+=("--targets")
+    flags+=("--watch-config")
     flags+=("--color=")
     two_word_flags+=("--color")
     flags+=("--config=")
@@ -2044,7 +2893,14 @@ _chezmoi_verify()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -2070,6 +2926,7 @@ _chezmoi_root_command()
     fi
     commands+=("apply")
     commands+=("archive")
+    commands+=("backups")
     commands+=("cat")
     commands+=("cd")
     commands+=("chattr")
@@ -2091,8 +2948,10 @@ _chezmoi_root_command()
     commands+=("hg")
     commands+=("import")
     commands+=("init")
+    commands+=("lint")
     commands+=("managed")
     commands+=("merge")
+    commands+=("merge-all")
     commands+=("purge")
     commands+=("remove")
     if [[ -z "${BASH_VERSION}" || "${BASH_VERSINFO[0]}" -gt 3 ]]; then
@@ -2106,6 +2965,7 @@ _chezmoi_root_command()
     commands+=("update")
     commands+=("upgrade")
     commands+=("verify")
+    commands+=("watch")
 
     flags=()
     two_word_flags=()
@@ -2129,7 +2989,14 @@ _chezmoi_root_command()
     flags+=("--dry-run")
     flags+=("-n")
     flags+=("--follow")
+    flags+=("--log-file=")
+    two_word_flags+=("--log-file")
+    flags_with_completion+=("--log-file")
+    flags_completion+=("_filedir")
+    flags+=("--log-format=")
+    two_word_flags+=("--log-format")
     flags+=("--remove")
+    flags+=("--show-secrets")
     flags+=("--source=")
     two_word_flags+=("--source")
     two_word_flags+=("-S")
@@ -10,7 +10,7 @@ require (
 	github.com/bmatcuk/doublestar v1.3.2
 	github.com/charmbracelet/glamour v0.2.0
 	github.com/coreos/go-semver v0.3.0
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/go-git/go-git/v5 v5.1.0
 	github.com/golang/protobuf v1.4.2 // indirect
 	github.com/google/go-github/v26 v26.1.3
@@ -46,6 +46,6 @@ require (
 	golang.org/x/sys v0.0.0-20200828194041-157a740278f4
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
-	gopkg.in/ini.v1 v1.60.0 // indirect
+	gopkg.in/ini.v1 v1.60.0
 	gopkg.in/yaml.v2 v2.3.0
 )
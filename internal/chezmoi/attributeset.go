@@ -0,0 +1,80 @@
+package chezmoi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// An Ownership holds the owner and group of a target. Empty values mean that
+// the owner or group is not managed.
+type Ownership struct {
+	Owner string
+	Group string
+}
+
+// An AttributeSet maps patterns to target attributes.
+type AttributeSet struct {
+	rules []attributeRule
+}
+
+type attributeRule struct {
+	pattern   string
+	ownership Ownership
+}
+
+// NewAttributeSet returns a new AttributeSet.
+func NewAttributeSet() *AttributeSet {
+	return &AttributeSet{}
+}
+
+// Add adds a pattern with ownership to as.
+func (as *AttributeSet) Add(pattern string, ownership Ownership) error {
+	if _, err := doublestar.PathMatch(pattern, ""); err != nil {
+		return err
+	}
+	as.rules = append(as.rules, attributeRule{
+		pattern:   pattern,
+		ownership: ownership,
+	})
+	return nil
+}
+
+// Match returns the ownership of name. Later patterns override earlier ones.
+func (as *AttributeSet) Match(name string) Ownership {
+	var ownership Ownership
+	for _, rule := range as.rules {
+		if ok, _ := doublestar.PathMatch(rule.pattern, name); !ok {
+			continue
+		}
+		if rule.ownership.Owner != "" {
+			ownership.Owner = rule.ownership.Owner
+		}
+		if rule.ownership.Group != "" {
+			ownership.Group = rule.ownership.Group
+		}
+	}
+	return ownership
+}
+
+// parseAttributes parses the attributes in fields, which are of the form
+// key=value.
+func parseAttributes(fields []string) (Ownership, error) {
+	var ownership Ownership
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return Ownership{}, fmt.Errorf("%s: invalid attribute", field)
+		}
+		switch kv[0] {
+		case "owner":
+			ownership.Owner = kv[1]
+		case "group":
+			ownership.Group = kv[1]
+		default:
+			return Ownership{}, fmt.Errorf("%s: unknown attribute", kv[0])
+		}
+	}
+	return ownership, nil
+}
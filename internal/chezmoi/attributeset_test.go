@@ -0,0 +1,55 @@
+package chezmoi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeSet(t *testing.T) {
+	as := NewAttributeSet()
+	require.NoError(t, as.Add("etc/**", Ownership{Owner: "root", Group: "root"}))
+	require.NoError(t, as.Add("etc/sudoers.d/*", Ownership{Group: "wheel"}))
+	for name, expected := range map[string]Ownership{
+		"foo":                                  {},
+		filepath.Join("etc", "hosts"):          {Owner: "root", Group: "root"},
+		filepath.Join("etc", "sudoers.d", "a"): {Owner: "root", Group: "wheel"},
+	} {
+		assert.Equal(t, expected, as.Match(name), name)
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	for _, tc := range []struct {
+		fields        []string
+		expected      Ownership
+		expectedError bool
+	}{
+		{
+			fields:   []string{"owner=root"},
+			expected: Ownership{Owner: "root"},
+		},
+		{
+			fields:   []string{"owner=0", "group=wheel"},
+			expected: Ownership{Owner: "0", Group: "wheel"},
+		},
+		{
+			fields:        []string{"owner"},
+			expectedError: true,
+		},
+		{
+			fields:        []string{"mode=0644"},
+			expectedError: true,
+		},
+	} {
+		actual, err := parseAttributes(tc.fields)
+		if tc.expectedError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		}
+	}
+}
@@ -1,70 +1,230 @@
 package chezmoi
 
 import (
+	"fmt"
 	"regexp"
-	"sort"
 	"strings"
 )
 
 var delimiterRegexp = regexp.MustCompile(`\{\{+|\}\}+`)
 
+// A WordBoundary determines which bytes autotemplating considers to be part of
+// words. Values are only replaced where they do not start or end in the middle
+// of a word.
+type WordBoundary string
+
+// Word boundaries.
+const (
+	WordBoundaryAlphanumeric WordBoundary = "alphanumeric" // Words contain ASCII letters and digits.
+	WordBoundaryIdentifier   WordBoundary = "identifier"   // Words contain ASCII letters, digits, and underscores.
+	WordBoundaryNone         WordBoundary = "none"         // Values are replaced anywhere.
+)
+
+// AutoTemplateOptions contains options for AutoTemplate.
+type AutoTemplateOptions struct {
+	// WordBoundary is the word boundary rule. The zero value is
+	// WordBoundaryAlphanumeric.
+	WordBoundary WordBoundary
+	// Exclude contains the names of template variables, for example
+	// "chezmoi.hostname", whose values are not replaced. Excluding a map
+	// excludes all the variables that it contains.
+	Exclude []string
+}
+
+// An AutoTemplateSubstitution is a value replaced by AutoTemplate.
+type AutoTemplateSubstitution struct {
+	Line  int // Line in the original contents, starting at 1.
+	Value string
+	Name  string
+}
+
 type templateVariable struct {
 	name  string
 	value string
 }
 
-type byValueLength []templateVariable
+// An autoTemplateMatcher finds the values of template variables in text in a
+// single pass using an Aho-Corasick automaton.
+type autoTemplateMatcher struct {
+	nodes     []autoTemplateNode
+	variables []templateVariable
+}
+
+// An autoTemplateNode is a node in an autoTemplateMatcher's automaton. Each
+// node corresponds to a prefix of one or more values.
+type autoTemplateNode struct {
+	next     map[byte]int
+	fail     int // Node of the longest proper suffix of this node's prefix.
+	variable int // Index of the variable whose value ends here, or -1.
+	output   int // Nearest node on the fail chain with a variable, or -1.
+	depth    int
+}
 
-func (b byValueLength) Len() int { return len(b) }
-func (b byValueLength) Less(i, j int) bool {
-	switch {
-	case len(b[i].value) < len(b[j].value):
-		return true
-	case len(b[i].value) == len(b[j].value):
-		// Fallback to name
-		return b[i].name > b[j].name
+// ParseWordBoundary parses s as a WordBoundary.
+func ParseWordBoundary(s string) (WordBoundary, error) {
+	switch wordBoundary := WordBoundary(s); wordBoundary {
+	case WordBoundaryAlphanumeric, WordBoundaryIdentifier, WordBoundaryNone:
+		return wordBoundary, nil
 	default:
-		return false
+		return WordBoundaryAlphanumeric, fmt.Errorf("%s: unknown word boundary", s)
 	}
 }
-func (b byValueLength) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
 
-func autoTemplate(contents []byte, data map[string]interface{}) []byte {
-	// FIXME this naive approach will generate incorrect templates if the
-	// variable names match variable values
-	// FIXME the algorithm here is probably O(N^2), we can do better
-	variables := extractVariables(nil, nil, data)
-	sort.Sort(sort.Reverse(byValueLength(variables)))
-	contentsStr := string(templateEscape(contents))
+// AutoTemplate returns contents as a template, replacing the values of the
+// template variables in data with references to those variables and escaping
+// any template delimiters. Where values overlap, the leftmost match is
+// replaced, and of matches starting at the same position, the longest. If
+// several variables have the same value then the first by name is used. It
+// also returns the substitutions made.
+func AutoTemplate(contents []byte, data map[string]interface{}, options AutoTemplateOptions) ([]byte, []AutoTemplateSubstitution) {
+	return autoTemplateVariables(contents, extractVariables(nil, nil, data), options)
+}
+
+// autoTemplateVariables returns contents as a template, replacing the values
+// of variables, and the substitutions made.
+func autoTemplateVariables(contents []byte, variables []templateVariable, options AutoTemplateOptions) ([]byte, []AutoTemplateSubstitution) {
+	m := newAutoTemplateMatcher(variables, options.Exclude)
+	s := string(contents)
+
+	// Find the longest acceptable match starting at each position.
+	longest := make([]int, len(s))
+	for i := range longest {
+		longest[i] = -1
+	}
+	m.match(s, func(start, variable int) {
+		end := start + len(m.variables[variable].value)
+		if options.WordBoundary.inWord(s, start) || options.WordBoundary.inWord(s, end) {
+			return
+		}
+		if inDelimiter(s, start) || inDelimiter(s, end) {
+			return
+		}
+		if longest[start] == -1 || len(m.variables[variable].value) > len(m.variables[longest[start]].value) {
+			longest[start] = variable
+		}
+	})
+
+	// Replace the leftmost matches, escaping the text between them.
+	var result []byte
+	var substitutions []AutoTemplateSubstitution
+	line := 1
+	lineOffset := 0
+	textStart := 0
+	for i := 0; i < len(s); {
+		if longest[i] == -1 {
+			i++
+			continue
+		}
+		variable := m.variables[longest[i]]
+		result = append(result, templateEscape([]byte(s[textStart:i]))...)
+		result = append(result, "{{ ."+variable.name+" }}"...)
+		line += strings.Count(s[lineOffset:i], "\n")
+		lineOffset = i
+		substitutions = append(substitutions, AutoTemplateSubstitution{
+			Line:  line,
+			Value: variable.value,
+			Name:  variable.name,
+		})
+		i += len(variable.value)
+		textStart = i
+	}
+	result = append(result, templateEscape([]byte(s[textStart:]))...)
+	return result, substitutions
+}
+
+// newAutoTemplateMatcher returns a new autoTemplateMatcher that matches the
+// non-empty values of variables, except those of the variables in exclude.
+func newAutoTemplateMatcher(variables []templateVariable, exclude []string) *autoTemplateMatcher {
+	m := &autoTemplateMatcher{
+		nodes: []autoTemplateNode{
+			{
+				variable: -1,
+				output:   -1,
+			},
+		},
+	}
+
+	// Build the trie of values. Where several variables have the same value,
+	// keep the first by name.
 	for _, variable := range variables {
-		if variable.value == "" {
+		if variable.value == "" || containsVariable(exclude, variable.name) {
 			continue
 		}
-		index := strings.Index(contentsStr, variable.value)
-		for index != -1 && index != len(contentsStr) {
-			if !inWord(contentsStr, index) && !inWord(contentsStr, index+len(variable.value)) {
-				// Replace variable.value which is on word boundaries at both
-				// ends.
-				replacement := "{{ ." + variable.name + " }}"
-				contentsStr = contentsStr[:index] + replacement + contentsStr[index+len(variable.value):]
-				index += len(replacement)
-			} else {
-				// Otherwise, keep looking. Consume at least one byte so we
-				// make progress.
-				index++
+		node := 0
+		for i := 0; i < len(variable.value); i++ {
+			next, ok := m.nodes[node].next[variable.value[i]]
+			if !ok {
+				next = len(m.nodes)
+				m.nodes = append(m.nodes, autoTemplateNode{
+					variable: -1,
+					output:   -1,
+					depth:    m.nodes[node].depth + 1,
+				})
+				if m.nodes[node].next == nil {
+					m.nodes[node].next = make(map[byte]int)
+				}
+				m.nodes[node].next[variable.value[i]] = next
 			}
-			// Look for the next occurrence of variable.value.
-			j := strings.Index(contentsStr[index:], variable.value)
-			if j == -1 {
-				// No more occurrences found, so terminate the loop.
-				break
+			node = next
+		}
+		switch existing := m.nodes[node].variable; {
+		case existing == -1:
+			m.nodes[node].variable = len(m.variables)
+			m.variables = append(m.variables, variable)
+		case variable.name < m.variables[existing].name:
+			m.variables[existing] = variable
+		}
+	}
+
+	// Compute the fail and output links in breadth-first order, so that the
+	// links of shorter prefixes are known before they are needed.
+	queue := []int{0}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for b, child := range m.nodes[node].next {
+			fail := 0
+			if node != 0 {
+				fail = m.step(m.nodes[node].fail, b)
+			}
+			m.nodes[child].fail = fail
+			if m.nodes[fail].variable != -1 {
+				m.nodes[child].output = fail
 			} else {
-				// Advance to the next occurrence.
-				index += j
+				m.nodes[child].output = m.nodes[fail].output
 			}
+			queue = append(queue, child)
+		}
+	}
+
+	return m
+}
+
+// match calls f with the start position and variable index of every match in
+// s.
+func (m *autoTemplateMatcher) match(s string, f func(int, int)) {
+	node := 0
+	for i := 0; i < len(s); i++ {
+		node = m.step(node, s[i])
+		for n := node; n > 0; n = m.nodes[n].output {
+			if variable := m.nodes[n].variable; variable != -1 {
+				f(i+1-m.nodes[n].depth, variable)
+			}
+		}
+	}
+}
+
+// step returns the node reached from node by consuming b.
+func (m *autoTemplateMatcher) step(node int, b byte) int {
+	for {
+		if next, ok := m.nodes[node].next[b]; ok {
+			return next
+		}
+		if node == 0 {
+			return 0
 		}
+		node = m.nodes[node].fail
 	}
-	return []byte(contentsStr)
 }
 
 func extractVariables(variables []templateVariable, parent []string, data map[string]interface{}) []templateVariable {
@@ -82,14 +242,38 @@ func extractVariables(variables []templateVariable, parent []string, data map[st
 	return variables
 }
 
+// containsVariable returns true if the variable name is in names or is
+// contained in a map in names.
+func containsVariable(names []string, name string) bool {
+	for _, n := range names {
+		if name == n || strings.HasPrefix(name, n+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// inDelimiter returns true if splitting s at position i would split a run of
+// braces that could form a template delimiter.
+func inDelimiter(s string, i int) bool {
+	return i > 0 && i < len(s) && s[i-1] == s[i] && (s[i] == '{' || s[i] == '}')
+}
+
 // inWord returns true if splitting s at position i would split a word.
-func inWord(s string, i int) bool {
-	return i > 0 && i < len(s) && isWord(s[i-1]) && isWord(s[i])
+func (b WordBoundary) inWord(s string, i int) bool {
+	return i > 0 && i < len(s) && b.isWord(s[i-1]) && b.isWord(s[i])
 }
 
-// isWord returns true if b is a word byte.
-func isWord(b byte) bool {
-	return '0' <= b && b <= '9' || 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z'
+// isWord returns true if c is a word byte.
+func (b WordBoundary) isWord(c byte) bool {
+	switch b {
+	case WordBoundaryIdentifier:
+		return '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || c == '_'
+	case WordBoundaryNone:
+		return false
+	default:
+		return '0' <= c && c <= '9' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z'
+	}
 }
 
 // templateEscape escapes any template delimiters in data.
@@ -4,14 +4,17 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAutoTemplate(t *testing.T) {
 	for _, tc := range []struct {
-		name        string
-		contentsStr string
-		data        map[string]interface{}
-		wantStr     string
+		name              string
+		contentsStr       string
+		data              map[string]interface{}
+		options           AutoTemplateOptions
+		wantStr           string
+		wantSubstitutions []AutoTemplateSubstitution
 	}{
 		{
 			name:        "simple",
@@ -122,13 +125,128 @@ func TestAutoTemplate(t *testing.T) {
 			},
 			wantStr: "a",
 		},
+		{
+			name:        "names_match_values",
+			contentsStr: "home name\n",
+			data: map[string]interface{}{
+				"home": "name",
+				"name": "home",
+			},
+			wantStr: "{{ .name }} {{ .home }}\n",
+		},
+		{
+			name:        "leftmost_first",
+			contentsStr: "John Smith\n",
+			data: map[string]interface{}{
+				"prefix": "Jo",
+				"suffix": "ohn Smith",
+			},
+			options: AutoTemplateOptions{
+				WordBoundary: WordBoundaryNone,
+			},
+			wantStr: "{{ .prefix }}hn Smith\n",
+		},
+		{
+			name:        "escape_delimiters",
+			contentsStr: "{{ user }}\n{user}\n",
+			data: map[string]interface{}{
+				"username": "user",
+			},
+			wantStr: "{{ \"{{\" }} {{ .username }} {{ \"}}\" }}\n{{{ .username }}}\n",
+		},
+		{
+			name:        "do_not_split_delimiters",
+			contentsStr: "{{{a}}}\n",
+			data: map[string]interface{}{
+				"value": "{a",
+			},
+			wantStr: "{{ \"{{{\" }}a{{ \"}}}\" }}\n",
+		},
+		{
+			name:        "word_boundary_identifier",
+			contentsStr: "user user_name\n",
+			data: map[string]interface{}{
+				"username": "user",
+			},
+			options: AutoTemplateOptions{
+				WordBoundary: WordBoundaryIdentifier,
+			},
+			wantStr: "{{ .username }} user_name\n",
+		},
+		{
+			name:        "word_boundary_none",
+			contentsStr: "darwinian evolution",
+			data: map[string]interface{}{
+				"os": "darwin",
+			},
+			options: AutoTemplateOptions{
+				WordBoundary: WordBoundaryNone,
+			},
+			wantStr: "{{ .os }}ian evolution",
+		},
+		{
+			name:        "exclude",
+			contentsStr: "host user\n",
+			data: map[string]interface{}{
+				"chezmoi": map[string]interface{}{
+					"hostname": "host",
+					"username": "user",
+				},
+				"user": "user",
+			},
+			options: AutoTemplateOptions{
+				Exclude: []string{"chezmoi", "user"},
+			},
+			wantStr: "host user\n",
+		},
+		{
+			name:        "exclude_nested",
+			contentsStr: "host user\n",
+			data: map[string]interface{}{
+				"chezmoi": map[string]interface{}{
+					"hostname": "host",
+					"username": "user",
+				},
+			},
+			options: AutoTemplateOptions{
+				Exclude: []string{"chezmoi.hostname"},
+			},
+			wantStr: "host {{ .chezmoi.username }}\n",
+		},
+		{
+			name:        "substitutions",
+			contentsStr: "[user]\n\temail = john.smith@company.com\n\n\tname = John Smith\n",
+			data: map[string]interface{}{
+				"email": "john.smith@company.com",
+				"name":  "John Smith",
+			},
+			wantStr: "[user]\n\temail = {{ .email }}\n\n\tname = {{ .name }}\n",
+			wantSubstitutions: []AutoTemplateSubstitution{
+				{Line: 2, Value: "john.smith@company.com", Name: "email"},
+				{Line: 4, Value: "John Smith", Name: "name"},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.wantStr, string(autoTemplate([]byte(tc.contentsStr), tc.data)))
+			got, gotSubstitutions := AutoTemplate([]byte(tc.contentsStr), tc.data, tc.options)
+			assert.Equal(t, tc.wantStr, string(got))
+			if tc.wantSubstitutions != nil {
+				assert.Equal(t, tc.wantSubstitutions, gotSubstitutions)
+			}
 		})
 	}
 }
 
+func TestParseWordBoundary(t *testing.T) {
+	for _, s := range []string{"alphanumeric", "identifier", "none"} {
+		wordBoundary, err := ParseWordBoundary(s)
+		require.NoError(t, err)
+		assert.Equal(t, WordBoundary(s), wordBoundary)
+	}
+	_, err := ParseWordBoundary("unknown")
+	assert.Error(t, err)
+}
+
 func TestInWord(t *testing.T) {
 	for _, tc := range []struct {
 		s    string
@@ -163,7 +281,7 @@ func TestInWord(t *testing.T) {
 		{s: "/home/user", i: 9, want: true},
 		{s: "/home/user", i: 10, want: false},
 	} {
-		assert.Equal(t, tc.want, inWord(tc.s, tc.i))
+		assert.Equal(t, tc.want, WordBoundaryAlphanumeric.inWord(tc.s, tc.i))
 	}
 }
 
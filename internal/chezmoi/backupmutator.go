@@ -0,0 +1,191 @@
+package chezmoi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// BackupTimeFormat is the format of the names of backup directories.
+const BackupTimeFormat = "20060102T150405Z"
+
+// A BackupMutator wraps a Mutator and copies every target in a destination
+// directory to a backup directory before it is overwritten or removed. Each
+// target is backed up at most once. Backups are written with a separate
+// Mutator, so a BackupMutator can wrap Mutators, like an EscalatingMutator,
+// that make changes without calling the Mutator that they wrap.
+//
+// Targets are read without escalated privileges. Targets that cannot be read,
+// for example root-owned files under escalated paths, are not backed up and a
+// warning is written instead.
+type BackupMutator struct {
+	w        io.Writer
+	m        Mutator
+	backupM  Mutator
+	fs       vfs.FS
+	destDir  string
+	dir      string
+	ignore   func(string) bool
+	backedUp map[string]bool
+}
+
+// NewBackupMutator returns a new BackupMutator that backs up targets in
+// destDir to dir using backupM, except for those for which ignore returns
+// true. Warnings are written to w.
+func NewBackupMutator(w io.Writer, m, backupM Mutator, fs vfs.FS, destDir, dir string, ignore func(string) bool) *BackupMutator {
+	return &BackupMutator{
+		w:        w,
+		m:        m,
+		backupM:  backupM,
+		fs:       fs,
+		destDir:  destDir,
+		dir:      dir,
+		ignore:   ignore,
+		backedUp: make(map[string]bool),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *BackupMutator) Chmod(name string, mode os.FileMode) error {
+	return m.m.Chmod(name, mode)
+}
+
+// Chown implements Mutator.Chown.
+func (m *BackupMutator) Chown(name string, uid, gid int) error {
+	return m.m.Chown(name, uid, gid)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *BackupMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *BackupMutator) Mkdir(name string, perm os.FileMode) error {
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *BackupMutator) RemoveAll(name string) error {
+	if err := m.backup(name); err != nil {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *BackupMutator) Rename(oldpath, newpath string) error {
+	if err := m.backup(newpath); err != nil {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *BackupMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *BackupMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *BackupMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if err := m.backup(name); err != nil {
+		return err
+	}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *BackupMutator) WriteSymlink(oldname, newname string) error {
+	if err := m.backup(newname); err != nil {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// backup copies name to the backup directory, if it exists, is in the
+// destination directory, and has not already been backed up.
+func (m *BackupMutator) backup(name string) error {
+	if m.backedUp[name] || (m.ignore != nil && m.ignore(name)) {
+		return nil
+	}
+	relPath, err := filepath.Rel(m.destDir, name)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	if _, err := m.fs.Lstat(name); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	backupPath := filepath.Join(m.dir, relPath)
+	if _, err := m.fs.Lstat(backupPath); err == nil {
+		m.backedUp[name] = true
+		return nil
+	}
+	if err := vfs.MkdirAll(m.backupM, filepath.Dir(backupPath), 0o700); err != nil {
+		return err
+	}
+	if err := CopyAll(m.fs, m.backupM, name, backupPath); os.IsPermission(err) {
+		// Remove any partial backup so that it is not mistaken for a
+		// complete one.
+		if err := m.backupM.RemoveAll(backupPath); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(m.w, "warning: %s: not backed up: %v\n", name, err); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	m.backedUp[name] = true
+	return nil
+}
+
+// CopyAll copies oldpath, which may be a directory, file, or symlink, to
+// newpath using mutator, preserving permissions.
+func CopyAll(fs vfs.FS, mutator Mutator, oldpath, newpath string) error {
+	info, err := fs.Lstat(oldpath)
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		if err := mutator.Mkdir(newpath, info.Mode().Perm()); err != nil {
+			return err
+		}
+		infos, err := fs.ReadDir(oldpath)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if err := CopyAll(fs, mutator, filepath.Join(oldpath, info.Name()), filepath.Join(newpath, info.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case info.Mode().IsRegular():
+		data, err := fs.ReadFile(oldpath)
+		if err != nil {
+			return err
+		}
+		return mutator.WriteFile(newpath, data, info.Mode().Perm(), nil)
+	case info.Mode()&os.ModeType == os.ModeSymlink:
+		linkname, err := fs.Readlink(oldpath)
+		if err != nil {
+			return err
+		}
+		return mutator.WriteSymlink(linkname, newpath)
+	default:
+		return fmt.Errorf("%s: unsupported file type", oldpath)
+	}
+}
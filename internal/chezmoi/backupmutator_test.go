@@ -0,0 +1,146 @@
+package chezmoi
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &BackupMutator{}
+
+func TestBackupMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": &vfst.File{
+				Perm:     0o644,
+				Contents: []byte("# old contents of .bashrc\n"),
+			},
+			".dir": map[string]interface{}{
+				"file": "# contents of .dir/file\n",
+			},
+			".local/share/chezmoi/dot_bashrc": "# contents of dot_bashrc\n",
+			".symlink":                        &vfst.Symlink{Target: ".bashrc"},
+		},
+		"/tmp": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ignore := func(name string) bool {
+		return name == "/home/user/.local/share/chezmoi/dot_bashrc"
+	}
+	m := NewBackupMutator(&strings.Builder{}, NewFSMutator(fs), NewFSMutator(fs), fs, "/home/user", "/home/user/.backups/1", ignore)
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# new contents of .bashrc\n"), 0o600, nil))
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("# newer contents of .bashrc\n"), 0o600, nil))
+	require.NoError(t, m.RemoveAll("/home/user/.dir"))
+	require.NoError(t, m.WriteSymlink(".dir", "/home/user/.symlink"))
+	require.NoError(t, m.WriteFile("/home/user/.new", []byte("# contents of .new\n"), 0o644, nil))
+	require.NoError(t, m.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", nil, 0o644, nil))
+	require.NoError(t, m.WriteFile("/tmp/file", nil, 0o644, nil))
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.backups/1/.bashrc",
+			vfst.TestModeIsRegular,
+			vfst.TestModePerm(0o644),
+			vfst.TestContentsString("# old contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.backups/1/.dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of .dir/file\n"),
+		),
+		vfst.TestPath("/home/user/.backups/1/.symlink",
+			vfst.TestModeType(os.ModeSymlink),
+			vfst.TestSymlinkTarget(".bashrc"),
+		),
+		vfst.TestPath("/home/user/.backups/1/.new",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.backups/1/.local",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.dir",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestBackupMutatorEscalated(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/hosts": &vfst.File{
+			Perm:     0o644,
+			Contents: []byte("127.0.0.1 localhost\n"),
+		},
+		"/var/backups": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	sb := &strings.Builder{}
+	escalatingMutator := NewEscalatingMutator(NewVerboseMutator(sb, NullMutator{}, false, 0, nil), "sudo", nil, []string{"/etc"})
+	m := NewBackupMutator(&strings.Builder{}, escalatingMutator, NewFSMutator(fs), fs, "/", "/var/backups/1", nil)
+	require.NoError(t, m.WriteFile("/etc/hosts", []byte("127.0.0.1 localhost.localdomain\n"), 0o644, nil))
+	require.NoError(t, m.RemoveAll("/etc/hosts"))
+
+	assert.Equal(t, strings.Join([]string{
+		"sudo install -m 644 /dev/stdin /etc/hosts",
+		"sudo rm -rf /etc/hosts",
+		"",
+	}, "\n"), sb.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/var/backups/1/etc/hosts",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("127.0.0.1 localhost\n"),
+		),
+	)
+}
+
+// An unreadableFS is a vfs.FS in which files cannot be read, as if they were
+// owned by another user.
+type unreadableFS struct {
+	vfs.FS
+}
+
+func (fs unreadableFS) ReadFile(name string) ([]byte, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrPermission}
+}
+
+func TestBackupMutatorUnreadable(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc": map[string]interface{}{
+			"hosts": "127.0.0.1 localhost\n",
+			"sudoers.d": map[string]interface{}{
+				"user": "user ALL=(ALL) ALL\n",
+			},
+		},
+		"/var/backups": &vfst.Dir{Perm: 0o755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	sb := &strings.Builder{}
+	m := NewBackupMutator(sb, NewFSMutator(fs), NewFSMutator(fs), unreadableFS{FS: fs}, "/", "/var/backups/1", nil)
+	require.NoError(t, m.WriteFile("/etc/hosts", []byte("127.0.0.1 localhost.localdomain\n"), 0o644, nil))
+	require.NoError(t, m.RemoveAll("/etc/sudoers.d"))
+
+	assert.Equal(t, strings.Join([]string{
+		"warning: /etc/hosts: not backed up: open /etc/hosts: permission denied",
+		"warning: /etc/sudoers.d: not backed up: open /etc/sudoers.d/user: permission denied",
+		"",
+	}, "\n"), sb.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/etc/hosts",
+			vfst.TestContentsString("127.0.0.1 localhost.localdomain\n"),
+		),
+		vfst.TestPath("/var/backups/1/etc/hosts",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/var/backups/1/etc/sudoers.d",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
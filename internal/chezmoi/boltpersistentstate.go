@@ -63,6 +63,20 @@ func (b *BoltPersistentState) Delete(bucket, key []byte) error {
 	})
 }
 
+// DeleteBucket deletes bucket and all the keys in it. If bucket does not exist
+// then DeleteBucket does nothing.
+func (b *BoltPersistentState) DeleteBucket(bucket []byte) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucket) == nil {
+			return nil
+		}
+		return tx.DeleteBucket(bucket)
+	})
+}
+
 // Get returns the value associated with key in bucket.
 func (b *BoltPersistentState) Get(bucket, key []byte) ([]byte, error) {
 	var value []byte
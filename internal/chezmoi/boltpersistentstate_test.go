@@ -71,6 +71,14 @@ func TestBoltPersistentState(t *testing.T) {
 	actualValue, err = b.Get(bucket, key)
 	require.NoError(t, err)
 	assert.Equal(t, []byte(nil), actualValue)
+
+	require.NoError(t, b.Set(bucket, key, value))
+	require.NoError(t, b.DeleteBucket(bucket))
+	require.NoError(t, b.DeleteBucket(bucket))
+
+	actualValue, err = b.Get(bucket, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(nil), actualValue)
 }
 
 func TestBoltPersistentStateReadOnly(t *testing.T) {
@@ -30,6 +30,7 @@ const (
 type PersistentState interface {
 	Close() error
 	Delete(bucket, key []byte) error
+	DeleteBucket(bucket []byte) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
@@ -39,6 +40,7 @@ type ApplyOptions struct {
 	DestDir           string
 	DryRun            bool
 	Ignore            func(string) bool
+	Include           *IncludeSet
 	PersistentState   PersistentState
 	Remove            bool
 	ScriptStateBucket []byte
@@ -51,11 +53,11 @@ type ApplyOptions struct {
 type Entry interface {
 	AppendAllEntries(allEntries []Entry) []Entry
 	Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error
-	ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error)
+	ConcreteValue(ignore func(string) bool, include *IncludeSet, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error)
 	Evaluate(ignore func(string) bool) error
 	SourceName() string
 	TargetName() string
-	archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error
+	archive(w *tar.Writer, ignore func(string) bool, include *IncludeSet, headerTemplate *tar.Header, umask os.FileMode) error
 }
 
 type parsedSourceFilePath struct {
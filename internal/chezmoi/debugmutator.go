@@ -9,28 +9,38 @@ import (
 
 // A DebugMutator wraps a Mutator and logs all of the actions it executes.
 type DebugMutator struct {
-	m Mutator
+	m        Mutator
+	redactor *Redactor
 }
 
-// NewDebugMutator returns a new DebugMutator.
-func NewDebugMutator(m Mutator) *DebugMutator {
+// NewDebugMutator returns a new DebugMutator. Secrets recorded by redactor are
+// masked in the logs.
+func NewDebugMutator(m Mutator, redactor *Redactor) *DebugMutator {
 	return &DebugMutator{
-		m: m,
+		m:        m,
+		redactor: redactor,
 	}
 }
 
 // Chmod implements Mutator.Chmod.
 func (m *DebugMutator) Chmod(name string, mode os.FileMode) error {
-	return Debugf("Chmod(%q, 0%o)", []interface{}{name, mode}, func() error {
+	return m.debugf("Chmod(%q, 0%o)", []interface{}{name, mode}, func() error {
 		return m.m.Chmod(name, mode)
 	})
 }
 
+// Chown implements Mutator.Chown.
+func (m *DebugMutator) Chown(name string, uid, gid int) error {
+	return m.debugf("Chown(%q, %d, %d)", []interface{}{name, uid, gid}, func() error {
+		return m.m.Chown(name, uid, gid)
+	})
+}
+
 // IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
 func (m *DebugMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	var output []byte
 	cmdStr := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
-	err := Debugf("IdempotentCmdOutput(%q)", []interface{}{cmdStr}, func() error {
+	err := m.debugf("IdempotentCmdOutput(%q)", []interface{}{cmdStr}, func() error {
 		var err error
 		output, err = m.m.IdempotentCmdOutput(cmd)
 		return err
@@ -40,21 +50,21 @@ func (m *DebugMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 
 // Mkdir implements Mutator.Mkdir.
 func (m *DebugMutator) Mkdir(name string, perm os.FileMode) error {
-	return Debugf("Mkdir(%q, 0%o)", []interface{}{name, perm}, func() error {
+	return m.debugf("Mkdir(%q, 0%o)", []interface{}{name, perm}, func() error {
 		return m.m.Mkdir(name, perm)
 	})
 }
 
 // RemoveAll implements Mutator.RemoveAll.
 func (m *DebugMutator) RemoveAll(name string) error {
-	return Debugf("RemoveAll(%q)", []interface{}{name}, func() error {
+	return m.debugf("RemoveAll(%q)", []interface{}{name}, func() error {
 		return m.m.RemoveAll(name)
 	})
 }
 
 // Rename implements Mutator.Rename.
 func (m *DebugMutator) Rename(oldpath, newpath string) error {
-	return Debugf("Rename(%q, %q)", []interface{}{oldpath, newpath}, func() error {
+	return m.debugf("Rename(%q, %q)", []interface{}{oldpath, newpath}, func() error {
 		return m.Rename(oldpath, newpath)
 	})
 }
@@ -62,7 +72,7 @@ func (m *DebugMutator) Rename(oldpath, newpath string) error {
 // RunCmd implements Mutator.RunCmd.
 func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
 	cmdStr := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
-	return Debugf("Run(%q)", []interface{}{cmdStr}, func() error {
+	return m.debugf("Run(%q)", []interface{}{cmdStr}, func() error {
 		return m.m.RunCmd(cmd)
 	})
 }
@@ -70,7 +80,7 @@ func (m *DebugMutator) RunCmd(cmd *exec.Cmd) error {
 // Stat implements Mutator.Stat.
 func (m *DebugMutator) Stat(name string) (os.FileInfo, error) {
 	var fi os.FileInfo
-	err := Debugf("Stat(%q)", []interface{}{name}, func() error {
+	err := m.debugf("Stat(%q)", []interface{}{name}, func() error {
 		var err error
 		fi, err = m.m.Stat(name)
 		return err
@@ -80,18 +90,30 @@ func (m *DebugMutator) Stat(name string) (os.FileInfo, error) {
 
 // WriteFile implements Mutator.WriteFile.
 func (m *DebugMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
-	return Debugf("WriteFile(%q, _, 0%o, _)", []interface{}{name, perm}, func() error {
+	return m.debugf("WriteFile(%q, _, 0%o, _)", []interface{}{name, perm}, func() error {
 		return m.m.WriteFile(name, data, perm, currData)
 	})
 }
 
 // WriteSymlink implements Mutator.WriteSymlink.
 func (m *DebugMutator) WriteSymlink(oldname, newname string) error {
-	return Debugf("WriteSymlink(%q, %q)", []interface{}{oldname, newname}, func() error {
+	return m.debugf("WriteSymlink(%q, %q)", []interface{}{oldname, newname}, func() error {
 		return m.m.WriteSymlink(oldname, newname)
 	})
 }
 
+// debugf calls Debugf with secrets in args masked.
+func (m *DebugMutator) debugf(format string, args []interface{}, f func() error) error {
+	redactedArgs := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		if s, ok := arg.(string); ok {
+			arg = m.redactor.RedactString(s)
+		}
+		redactedArgs = append(redactedArgs, arg)
+	}
+	return Debugf(format, redactedArgs, f)
+}
+
 // Debugf logs debugging information about calling f.
 func Debugf(format string, args []interface{}, f func() error) error {
 	errChan := make(chan error)
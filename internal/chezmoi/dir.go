@@ -22,6 +22,7 @@ type Dir struct {
 	targetName string
 	Exact      bool
 	Perm       os.FileMode
+	Ownership  Ownership
 	Entries    map[string]Entry
 }
 
@@ -31,6 +32,8 @@ type dirConcreteValue struct {
 	TargetPath string        `json:"targetPath" yaml:"targetPath"`
 	Exact      bool          `json:"exact" yaml:"exact"`
 	Perm       int           `json:"perm" yaml:"perm"`
+	Owner      string        `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Group      string        `json:"group,omitempty" yaml:"group,omitempty"`
 	Entries    []interface{} `json:"entries" yaml:"entries"`
 }
 
@@ -96,9 +99,12 @@ func (d *Dir) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that destDir in fs matches d.
 func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(d.targetName) {
+	if applyOptions.Ignore(d.targetName) || !applyOptions.Include.includeDir(d) {
 		return nil
 	}
+	// If d is not included itself, but contains included entries, then only
+	// create it if needed.
+	include := applyOptions.Include.IncludeEntry(d)
 	targetPath := filepath.Join(applyOptions.DestDir, d.targetName)
 	var info os.FileInfo
 	var err error
@@ -109,7 +115,7 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	}
 	switch {
 	case err == nil && info.IsDir():
-		if info.Mode().Perm() != d.Perm&^applyOptions.Umask {
+		if include && info.Mode().Perm() != d.Perm&^applyOptions.Umask {
 			if err := mutator.Chmod(targetPath, d.Perm&^applyOptions.Umask); err != nil {
 				return err
 			}
@@ -126,14 +132,24 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 	default:
 		return err
 	}
+	if include {
+		if err := ensureOwnership(fs, mutator, targetPath, d.Ownership); err != nil {
+			return err
+		}
+	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
 		if err := d.Entries[entryName].Apply(fs, mutator, follow, applyOptions); err != nil {
 			return err
 		}
 	}
-	if d.Exact {
+	if include && d.Exact {
 		infos, err := fs.ReadDir(targetPath)
-		if err != nil {
+		switch {
+		case os.IsNotExist(err):
+			// The directory was not created, for example because this is a
+			// dry run or the user declined to create it.
+			return nil
+		case err != nil:
 			return err
 		}
 		for _, info := range infos {
@@ -152,14 +168,14 @@ func (d *Dir) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Apply
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(d.targetName) {
+func (d *Dir) ConcreteValue(ignore func(string) bool, include *IncludeSet, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(d.targetName) || !include.includeDir(d) {
 		return nil, nil
 	}
 	var entryConcreteValues []interface{}
 	if recursive {
 		for _, entryName := range sortedEntryNames(d.Entries) {
-			entryConcreteValue, err := d.Entries[entryName].ConcreteValue(ignore, sourceDir, umask, recursive)
+			entryConcreteValue, err := d.Entries[entryName].ConcreteValue(ignore, include, sourceDir, umask, recursive)
 			if err != nil {
 				return nil, err
 			}
@@ -174,6 +190,8 @@ func (d *Dir) ConcreteValue(ignore func(string) bool, sourceDir string, umask os
 		TargetPath: d.TargetName(),
 		Exact:      d.Exact,
 		Perm:       int(d.Perm &^ umask),
+		Owner:      d.Ownership.Owner,
+		Group:      d.Ownership.Group,
 		Entries:    entryConcreteValues,
 	}, nil
 }
@@ -207,19 +225,21 @@ func (d *Dir) TargetName() string {
 }
 
 // archive writes d to w.
-func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(d.targetName) {
+func (d *Dir) archive(w *tar.Writer, ignore func(string) bool, include *IncludeSet, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(d.targetName) || !include.includeDir(d) {
 		return nil
 	}
-	header := *headerTemplate
-	header.Typeflag = tar.TypeDir
-	header.Name = d.targetName + "/"
-	header.Mode = int64(d.Perm &^ umask)
-	if err := w.WriteHeader(&header); err != nil {
-		return err
+	if include.IncludeEntry(d) {
+		header := *headerTemplate
+		header.Typeflag = tar.TypeDir
+		header.Name = d.targetName + "/"
+		header.Mode = int64(d.Perm &^ umask)
+		if err := w.WriteHeader(&header); err != nil {
+			return err
+		}
 	}
 	for _, entryName := range sortedEntryNames(d.Entries) {
-		if err := d.Entries[entryName].archive(w, ignore, headerTemplate, umask); err != nil {
+		if err := d.Entries[entryName].archive(w, ignore, include, headerTemplate, umask); err != nil {
 			return err
 		}
 	}
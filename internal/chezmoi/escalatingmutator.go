@@ -0,0 +1,148 @@
+package chezmoi
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// An EscalatingMutator wraps a Mutator and performs changes to paths under any
+// of its prefixes with escalated privileges by running the equivalent shell
+// commands with an escalation command, like sudo or doas. The shell commands
+// are run with the wrapped Mutator's RunCmd, so dry runs and verbose output
+// show which changes are escalated.
+//
+// Only changes are escalated. Stat, and the reads of the current state of
+// targets that precede changes, are made with the caller's own privileges, so
+// targets that the caller cannot read cannot be managed.
+type EscalatingMutator struct {
+	m        Mutator
+	command  string
+	args     []string
+	prefixes []string
+}
+
+// NewEscalatingMutator returns a new EscalatingMutator.
+func NewEscalatingMutator(m Mutator, command string, args, prefixes []string) *EscalatingMutator {
+	cleanPrefixes := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		cleanPrefixes = append(cleanPrefixes, filepath.Clean(prefix))
+	}
+	return &EscalatingMutator{
+		m:        m,
+		command:  command,
+		args:     args,
+		prefixes: cleanPrefixes,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *EscalatingMutator) Chmod(name string, mode os.FileMode) error {
+	if !m.escalate(name) {
+		return m.m.Chmod(name, mode)
+	}
+	return m.run(nil, "chmod", strconv.FormatUint(uint64(mode.Perm()), 8), name)
+}
+
+// Chown implements Mutator.Chown.
+func (m *EscalatingMutator) Chown(name string, uid, gid int) error {
+	if !m.escalate(name) {
+		return m.m.Chown(name, uid, gid)
+	}
+	var ownership string
+	if uid != -1 {
+		ownership = strconv.Itoa(uid)
+	}
+	if gid != -1 {
+		ownership += ":" + strconv.Itoa(gid)
+	}
+	return m.run(nil, "chown", ownership, name)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *EscalatingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *EscalatingMutator) Mkdir(name string, perm os.FileMode) error {
+	if !m.escalate(name) {
+		return m.m.Mkdir(name, perm)
+	}
+	return m.run(nil, "mkdir", "-m", strconv.FormatUint(uint64(perm), 8), name)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *EscalatingMutator) RemoveAll(name string) error {
+	if !m.escalate(name) {
+		return m.m.RemoveAll(name)
+	}
+	return m.run(nil, "rm", "-rf", name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *EscalatingMutator) Rename(oldpath, newpath string) error {
+	if !m.escalate(oldpath) && !m.escalate(newpath) {
+		return m.m.Rename(oldpath, newpath)
+	}
+	return m.run(nil, "mv", oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *EscalatingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat. Stat is never escalated.
+func (m *EscalatingMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *EscalatingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if !m.escalate(name) {
+		return m.m.WriteFile(name, data, perm, currData)
+	}
+	return m.run(data, "install", "-m", strconv.FormatUint(uint64(perm), 8), "/dev/stdin", name)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *EscalatingMutator) WriteSymlink(oldname, newname string) error {
+	if !m.escalate(newname) {
+		return m.m.WriteSymlink(oldname, newname)
+	}
+	return m.run(nil, "ln", "-sfn", oldname, newname)
+}
+
+// escalate returns true if changes to name should be escalated.
+func (m *EscalatingMutator) escalate(name string) bool {
+	name = filepath.Clean(name)
+	for _, prefix := range m.prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs name with args with escalated privileges, passing stdin if it is
+// not nil.
+func (m *EscalatingMutator) run(stdin []byte, name string, args ...string) error {
+	//nolint:gosec
+	cmd := exec.Command(m.command, append(append(append([]string{}, m.args...), name), args...)...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	} else {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := m.m.RunCmd(cmd); err != nil {
+		return fmt.Errorf("%s: %w", cmdString(cmd), err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package chezmoi
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &EscalatingMutator{}
+
+func TestEscalatingMutator(t *testing.T) {
+	sb := &strings.Builder{}
+	m := NewEscalatingMutator(NewVerboseMutator(sb, NullMutator{}, false, 0, nil), "/usr/bin/doas", []string{"-n"}, []string{"/etc/"})
+	require.NoError(t, m.Chmod("/etc/hosts", 0o644))
+	require.NoError(t, m.Chown("/etc/hosts", 0, -1))
+	require.NoError(t, m.Mkdir("/etc/foo", 0o755))
+	require.NoError(t, m.RemoveAll("/etc/bar"))
+	require.NoError(t, m.Rename("/home/user/baz", "/etc/baz"))
+	require.NoError(t, m.WriteFile("/etc/motd", []byte("hello\n"), 0o644, nil))
+	require.NoError(t, m.WriteSymlink("target", "/etc/link"))
+	require.NoError(t, m.Chmod("/etcetera", 0o600))
+	require.NoError(t, m.RemoveAll("/home/user/.bashrc"))
+	assert.Equal(t, strings.Join([]string{
+		"/usr/bin/doas -n chmod 644 /etc/hosts",
+		"/usr/bin/doas -n chown 0 /etc/hosts",
+		"/usr/bin/doas -n mkdir -m 755 /etc/foo",
+		"/usr/bin/doas -n rm -rf /etc/bar",
+		"/usr/bin/doas -n mv /home/user/baz /etc/baz",
+		"/usr/bin/doas -n install -m 644 /dev/stdin /etc/motd",
+		"/usr/bin/doas -n ln -sfn target /etc/link",
+		"chmod 600 /etcetera",
+		"rm -rf /home/user/.bashrc",
+		"",
+	}, "\n"), sb.String())
+}
+
+func TestEscalatingMutatorReadsNotEscalated(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc/hosts": "127.0.0.1 localhost\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	sb := &strings.Builder{}
+	m := NewEscalatingMutator(NewVerboseMutator(sb, NewFSMutator(fs), false, 0, nil), "sudo", nil, []string{"/etc"})
+
+	info, err := m.Stat("/etc/hosts")
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+
+	f := &File{
+		targetName: "etc/hosts",
+		Perm:       0o644,
+		contents:   []byte("127.0.0.1 localhost.localdomain\n"),
+	}
+	err = f.Apply(unreadableFS{FS: fs}, m, false, &ApplyOptions{
+		DestDir: "/",
+		Ignore:  func(string) bool { return false },
+	})
+	assert.True(t, os.IsPermission(err))
+	assert.Equal(t, "", sb.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/etc/hosts",
+			vfst.TestContentsString("127.0.0.1 localhost\n"),
+		),
+	)
+}
@@ -0,0 +1,74 @@
+package chezmoi
+
+import "sync"
+
+// EvaluateEntries evaluates entries, and all entries in any directories in
+// entries, using at most parallelism goroutines. Entries that are ignored or
+// not included are not evaluated. If evaluating any entry fails then the error
+// from the first failing entry, in the order that entries are applied, is
+// returned, so errors are reported deterministically.
+func EvaluateEntries(entries []Entry, ignore func(string) bool, include *IncludeSet, parallelism int) error {
+	var leafEntries []Entry
+	for _, entry := range entries {
+		leafEntries = appendLeafEntries(leafEntries, entry, ignore, include)
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > len(leafEntries) {
+		parallelism = len(leafEntries)
+	}
+
+	errs := make([]error, len(leafEntries))
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				errs[index] = leafEntries[index].Evaluate(ignore)
+			}
+		}()
+	}
+	for index := range leafEntries {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvaluateConcurrently evaluates all entries in ts that are included by
+// include using at most parallelism goroutines.
+func (ts *TargetState) EvaluateConcurrently(include *IncludeSet, parallelism int) error {
+	entries := make([]Entry, 0, len(ts.Entries))
+	for _, entryName := range sortedEntryNames(ts.Entries) {
+		entries = append(entries, ts.Entries[entryName])
+	}
+	return EvaluateEntries(entries, ts.TargetIgnore.Match, include, parallelism)
+}
+
+// appendLeafEntries appends entry, or all the non-directory entries in entry
+// if entry is a directory, to leafEntries in the order that they are applied.
+func appendLeafEntries(leafEntries []Entry, entry Entry, ignore func(string) bool, include *IncludeSet) []Entry {
+	if ignore(entry.TargetName()) {
+		return leafEntries
+	}
+	if dir, ok := entry.(*Dir); ok {
+		for _, entryName := range sortedEntryNames(dir.Entries) {
+			leafEntries = appendLeafEntries(leafEntries, dir.Entries[entryName], ignore, include)
+		}
+		return leafEntries
+	}
+	if !include.IncludeEntry(entry) {
+		return leafEntries
+	}
+	return append(leafEntries, entry)
+}
@@ -0,0 +1,77 @@
+package chezmoi
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateEntries(t *testing.T) {
+	var (
+		mutex         sync.Mutex
+		running       int
+		maxRunning    int
+		evaluated     = make(map[string]bool)
+		errorsByName  = map[string]error{"dir/b": errors.New("b"), "d": errors.New("d")}
+		blockingGroup sync.WaitGroup
+	)
+	blockingGroup.Add(1)
+	newTestEntry := func(targetName string) Entry {
+		return &File{
+			targetName: targetName,
+			evaluateContents: func() ([]byte, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				evaluated[targetName] = true
+				mutex.Unlock()
+				blockingGroup.Wait()
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return []byte(targetName), errorsByName[targetName]
+			},
+		}
+	}
+	dir := newDir("dir", "dir", false, 0o777)
+	for _, name := range []string{"c", "b", "a"} {
+		dir.Entries[name] = newTestEntry("dir/" + name)
+	}
+	dir.Entries["ignored"] = newTestEntry("dir/ignored")
+	dir.Entries["script"] = &Script{targetName: "dir/script"}
+	entries := []Entry{dir, newTestEntry("d"), newTestEntry("e")}
+	ignore := func(targetName string) bool {
+		return targetName == "dir/ignored"
+	}
+	include, err := NewIncludeSet(nil, []string{"scripts"})
+	assert.NoError(t, err)
+
+	go func() {
+		// Release the evaluations once enough of them are running at the
+		// same time.
+		for {
+			mutex.Lock()
+			n := running
+			mutex.Unlock()
+			if n == 2 {
+				blockingGroup.Done()
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, errors.New("b"), EvaluateEntries(entries, ignore, include, 2))
+	assert.Equal(t, 2, maxRunning)
+	for _, name := range []string{"dir/a", "dir/b", "dir/c", "d", "e"} {
+		assert.True(t, evaluated[name], strconv.Quote(name))
+	}
+	assert.False(t, evaluated["dir/ignored"])
+	contents, err := entries[2].(*File).Contents()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("e"), contents)
+}
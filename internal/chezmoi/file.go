@@ -27,6 +27,7 @@ type File struct {
 	Empty            bool
 	Encrypted        bool
 	Perm             os.FileMode
+	Ownership        Ownership
 	Template         bool
 	contents         []byte
 	contentsErr      error
@@ -40,6 +41,8 @@ type fileConcreteValue struct {
 	Empty      bool   `json:"empty" yaml:"empty"`
 	Encrypted  bool   `json:"encrypted" yaml:"encrypted"`
 	Perm       int    `json:"perm" yaml:"perm"`
+	Owner      string `json:"owner,omitempty" yaml:"owner,omitempty"`
+	Group      string `json:"group,omitempty" yaml:"group,omitempty"`
 	Template   bool   `json:"template" yaml:"template"`
 	Contents   string `json:"contents" yaml:"contents"`
 }
@@ -133,7 +136,7 @@ func (f *File) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply ensures that the state of targetPath in fs matches f.
 func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(f.targetName) {
+	if applyOptions.Ignore(f.targetName) || !applyOptions.Include.IncludeEntry(f) {
 		return nil
 	}
 	contents, err := f.Contents()
@@ -165,7 +168,7 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 				return err
 			}
 		}
-		return nil
+		return ensureOwnership(fs, mutator, targetPath, f.Ownership)
 	case err == nil:
 		if err := mutator.RemoveAll(targetPath); err != nil {
 			return err
@@ -177,12 +180,15 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	if isEmpty(contents) && !f.Empty {
 		return nil
 	}
-	return mutator.WriteFile(targetPath, contents, f.Perm&^applyOptions.Umask, currData)
+	if err := mutator.WriteFile(targetPath, contents, f.Perm&^applyOptions.Umask, currData); err != nil {
+		return err
+	}
+	return ensureOwnership(fs, mutator, targetPath, f.Ownership)
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(f.targetName) {
+func (f *File) ConcreteValue(ignore func(string) bool, include *IncludeSet, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(f.targetName) || !include.IncludeEntry(f) {
 		return nil, nil
 	}
 	contents, err := f.Contents()
@@ -196,6 +202,8 @@ func (f *File) ConcreteValue(ignore func(string) bool, sourceDir string, umask o
 		Empty:      f.Empty,
 		Encrypted:  f.Encrypted,
 		Perm:       int(f.Perm &^ umask),
+		Owner:      f.Ownership.Owner,
+		Group:      f.Ownership.Group,
 		Template:   f.Template,
 		Contents:   string(contents),
 	}, nil
@@ -240,8 +248,8 @@ func (f *File) TargetName() string {
 }
 
 // archive writes f to w.
-func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(f.targetName) {
+func (f *File) archive(w *tar.Writer, ignore func(string) bool, include *IncludeSet, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(f.targetName) || !include.IncludeEntry(f) {
 		return nil
 	}
 	contents, err := f.Contents()
@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
@@ -18,14 +19,17 @@ type GitDiffMutator struct {
 	m              Mutator
 	prefix         string
 	unifiedEncoder *diff.UnifiedEncoder
+	redactor       *Redactor
 }
 
-// NewGitDiffMutator returns a new GitDiffMutator.
-func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, prefix string) *GitDiffMutator {
+// NewGitDiffMutator returns a new GitDiffMutator. Secrets recorded by redactor
+// are masked in the diff.
+func NewGitDiffMutator(unifiedEncoder *diff.UnifiedEncoder, m Mutator, prefix string, redactor *Redactor) *GitDiffMutator {
 	return &GitDiffMutator{
 		m:              m,
 		prefix:         prefix,
 		unifiedEncoder: unifiedEncoder,
+		redactor:       redactor,
 	}
 }
 
@@ -59,6 +63,14 @@ func (m *GitDiffMutator) Chmod(name string, mode os.FileMode) error {
 	})
 }
 
+// Chown implements Mutator.Chown. git diffs cannot represent ownership, so the
+// change is written as the patch message.
+func (m *GitDiffMutator) Chown(name string, uid, gid int) error {
+	return m.unifiedEncoder.Encode(&gitDiffPatch{
+		message: fmt.Sprintf("chown %d:%d %s", uid, gid, m.trimPrefix(name)),
+	})
+}
+
 // IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
 func (m *GitDiffMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	return m.m.IdempotentCmdOutput(cmd)
@@ -147,7 +159,7 @@ func (m *GitDiffMutator) WriteFile(filename string, data []byte, perm os.FileMod
 	isBinary := isBinary(currData) || isBinary(data)
 	var chunks []diff.Chunk
 	if !isBinary {
-		chunks = diffChunks(string(currData), string(data))
+		chunks = diffChunks(string(m.redactor.Redact(currData)), string(m.redactor.Redact(data)))
 	}
 	return m.unifiedEncoder.Encode(&gitDiffPatch{
 		filePatches: []diff.FilePatch{
@@ -181,7 +193,7 @@ func (m *GitDiffMutator) WriteSymlink(oldname, newname string) error {
 				},
 				chunks: []diff.Chunk{
 					&gitDiffChunk{
-						content:   oldname,
+						content:   m.redactor.RedactString(oldname),
 						operation: diff.Add,
 					},
 				},
@@ -0,0 +1,32 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGPGEncrypt(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "chezmoi-test-gpg")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// gpg is replaced by a script that copies its last argument, the file to
+	// encrypt, to the output file.
+	command := filepath.Join(tempDir, "gpg")
+	require.NoError(t, ioutil.WriteFile(command, []byte("#!/bin/sh\n\nfor arg; do input=$arg; done\ncp \"$input\" \"$3\"\n"), 0o755))
+
+	g := &GPG{
+		Command:   command,
+		Symmetric: true,
+	}
+	ciphertext, err := g.Encrypt("secret", []byte("plaintext\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext\n", string(ciphertext))
+}
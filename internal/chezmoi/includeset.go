@@ -0,0 +1,119 @@
+package chezmoi
+
+import "fmt"
+
+// An entryTypeBits is a set of entry types.
+type entryTypeBits int
+
+const (
+	entryTypeDirs entryTypeBits = 1 << iota
+	entryTypeFiles
+	entryTypeSymlinks
+	entryTypeScripts
+	entryTypeEncrypted
+	entryTypeTemplates
+
+	entryTypesAll entryTypeBits = entryTypeDirs | entryTypeFiles | entryTypeSymlinks | entryTypeScripts | entryTypeEncrypted | entryTypeTemplates
+)
+
+var entryTypeBitsByName = map[string]entryTypeBits{
+	"all":       entryTypesAll,
+	"dirs":      entryTypeDirs,
+	"d":         entryTypeDirs,
+	"files":     entryTypeFiles,
+	"f":         entryTypeFiles,
+	"symlinks":  entryTypeSymlinks,
+	"s":         entryTypeSymlinks,
+	"scripts":   entryTypeScripts,
+	"encrypted": entryTypeEncrypted,
+	"templates": entryTypeTemplates,
+}
+
+// An IncludeSet controls which types of entries are included. A nil
+// *IncludeSet includes all entries.
+type IncludeSet struct {
+	include entryTypeBits
+	exclude entryTypeBits
+}
+
+// NewIncludeSet returns a new IncludeSet that includes entries of any of the
+// types in include, unless they are of any of the types in exclude. If
+// include is empty then all types are included.
+func NewIncludeSet(include, exclude []string) (*IncludeSet, error) {
+	s := &IncludeSet{}
+	if len(include) == 0 {
+		s.include = entryTypesAll
+	}
+	for _, name := range include {
+		bits, ok := entryTypeBitsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized include: %q", name)
+		}
+		s.include |= bits
+	}
+	for _, name := range exclude {
+		bits, ok := entryTypeBitsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized exclude: %q", name)
+		}
+		s.exclude |= bits
+	}
+	return s, nil
+}
+
+// IncludeEntry returns true if entry is included by s.
+func (s *IncludeSet) IncludeEntry(entry Entry) bool {
+	if s == nil {
+		return true
+	}
+	bits := getEntryTypeBits(entry)
+	return bits&s.include != 0 && bits&s.exclude == 0
+}
+
+// includeDir returns true if d, or any entry in d, is included by s.
+func (s *IncludeSet) includeDir(d *Dir) bool {
+	if s.IncludeEntry(d) {
+		return true
+	}
+	for _, entry := range d.Entries {
+		if subdir, ok := entry.(*Dir); ok {
+			if s.includeDir(subdir) {
+				return true
+			}
+		} else if s.IncludeEntry(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// getEntryTypeBits returns the types of entry.
+func getEntryTypeBits(entry Entry) entryTypeBits {
+	switch entry := entry.(type) {
+	case *Dir:
+		return entryTypeDirs
+	case *File:
+		bits := entryTypeFiles
+		if entry.Encrypted {
+			bits |= entryTypeEncrypted
+		}
+		if entry.Template {
+			bits |= entryTypeTemplates
+		}
+		return bits
+	case *Script:
+		bits := entryTypeScripts
+		if entry.Template {
+			bits |= entryTypeTemplates
+		}
+		return bits
+	case *Symlink:
+		bits := entryTypeSymlinks
+		if entry.Template {
+			bits |= entryTypeTemplates
+		}
+		return bits
+	default:
+		return 0
+	}
+}
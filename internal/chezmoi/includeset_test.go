@@ -0,0 +1,140 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestIncludeSet(t *testing.T) {
+	var (
+		dir             = &Dir{}
+		file            = &File{}
+		encryptedFile   = &File{Encrypted: true}
+		templateFile    = &File{Template: true}
+		script          = &Script{}
+		templateScript  = &Script{Template: true}
+		symlink         = &Symlink{}
+		templateSymlink = &Symlink{Template: true}
+		allEntries      = []Entry{dir, file, encryptedFile, templateFile, script, templateScript, symlink, templateSymlink}
+	)
+	for _, tc := range []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []Entry
+	}{
+		{
+			name:     "default",
+			expected: allEntries,
+		},
+		{
+			name:     "all",
+			include:  []string{"all"},
+			expected: allEntries,
+		},
+		{
+			name:     "abbreviations",
+			include:  []string{"d", "f", "s"},
+			expected: []Entry{dir, file, encryptedFile, templateFile, symlink, templateSymlink},
+		},
+		{
+			name:     "encrypted",
+			include:  []string{"encrypted"},
+			expected: []Entry{encryptedFile},
+		},
+		{
+			name:     "templates",
+			include:  []string{"templates"},
+			expected: []Entry{templateFile, templateScript, templateSymlink},
+		},
+		{
+			name:     "exclude_scripts",
+			exclude:  []string{"scripts"},
+			expected: []Entry{dir, file, encryptedFile, templateFile, symlink, templateSymlink},
+		},
+		{
+			name:     "exclude_templates",
+			include:  []string{"scripts"},
+			exclude:  []string{"templates"},
+			expected: []Entry{script},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewIncludeSet(tc.include, tc.exclude)
+			require.NoError(t, err)
+			var actual []Entry
+			for _, entry := range allEntries {
+				if s.IncludeEntry(entry) {
+					actual = append(actual, entry)
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestIncludeSetErrors(t *testing.T) {
+	_, err := NewIncludeSet([]string{"unknown"}, nil)
+	assert.Error(t, err)
+	_, err = NewIncludeSet(nil, []string{"unknown"})
+	assert.Error(t, err)
+}
+
+func TestTargetStateApplyIncludeSet(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".local/share/chezmoi": map[string]interface{}{
+				"exact_private_dir": map[string]interface{}{
+					"file":         "# contents of file\n",
+					"symlink_link": "file",
+				},
+				"dot_bashrc.tmpl": "# contents of .bashrc\n",
+			},
+			"dir": &vfst.Dir{
+				Perm: 0o755,
+				Entries: map[string]interface{}{
+					"extra": "# contents of extra\n",
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	include, err := NewIncludeSet([]string{"files"}, []string{"templates"})
+	require.NoError(t, err)
+	require.NoError(t, ts.Apply(fs, NewFSMutator(fs), false, &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Include: include,
+		Umask:   0o22,
+	}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/dir",
+			vfst.TestIsDir,
+			vfst.TestModePerm(0o755),
+		),
+		vfst.TestPath("/home/user/dir/extra",
+			vfst.TestModeIsRegular,
+		),
+		vfst.TestPath("/home/user/dir/file",
+			vfst.TestModeIsRegular,
+			vfst.TestContentsString("# contents of file\n"),
+		),
+		vfst.TestPath("/home/user/dir/link",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -0,0 +1,192 @@
+package chezmoi
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// An InteractiveMutator wraps a Mutator and asks the user whether to apply
+// each change to a target, after showing the change. Answers apply to all
+// changes to a target, and declining a change to a directory also declines
+// all changes to the targets inside it.
+type InteractiveMutator struct {
+	m         Mutator
+	display   Mutator
+	prompt    func(string, string) (byte, error)
+	merge     func(string) error
+	all       bool
+	diffOnly  bool
+	quit      bool
+	decisions map[string]bool
+}
+
+// NewInteractiveMutator returns a new InteractiveMutator that shows changes
+// on w, with secrets masked by redactor, and asks the user for a choice with
+// prompt. If merge is not nil then the user is also offered the option to
+// merge changes to files with merge.
+func NewInteractiveMutator(m Mutator, w io.Writer, colored bool, maxDiffDataSize int, redactor *Redactor, prompt func(string, string) (byte, error), merge func(string) error) *InteractiveMutator {
+	return &InteractiveMutator{
+		m:         m,
+		display:   NewVerboseMutator(w, NullMutator{}, colored, maxDiffDataSize, redactor),
+		prompt:    prompt,
+		merge:     merge,
+		decisions: make(map[string]bool),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *InteractiveMutator) Chmod(name string, mode os.FileMode) error {
+	if ok, err := m.confirm(name, false, func(display Mutator) error {
+		return display.Chmod(name, mode)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.Chmod(name, mode)
+}
+
+// Chown implements Mutator.Chown.
+func (m *InteractiveMutator) Chown(name string, uid, gid int) error {
+	if ok, err := m.confirm(name, false, func(display Mutator) error {
+		return display.Chown(name, uid, gid)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.Chown(name, uid, gid)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *InteractiveMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *InteractiveMutator) Mkdir(name string, perm os.FileMode) error {
+	if ok, err := m.confirm(name, false, func(display Mutator) error {
+		return display.Mkdir(name, perm)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *InteractiveMutator) RemoveAll(name string) error {
+	if ok, err := m.confirm(name, false, func(display Mutator) error {
+		return display.RemoveAll(name)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *InteractiveMutator) Rename(oldpath, newpath string) error {
+	if ok, err := m.confirm(newpath, false, func(display Mutator) error {
+		return display.Rename(oldpath, newpath)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *InteractiveMutator) RunCmd(cmd *exec.Cmd) error {
+	if ok, err := m.confirm(cmdString(cmd), false, func(display Mutator) error {
+		return display.RunCmd(cmd)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *InteractiveMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *InteractiveMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if ok, err := m.confirm(name, true, func(display Mutator) error {
+		return display.WriteFile(name, data, perm, currData)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *InteractiveMutator) WriteSymlink(oldname, newname string) error {
+	if ok, err := m.confirm(newname, false, func(display Mutator) error {
+		return display.WriteSymlink(oldname, newname)
+	}); err != nil || !ok {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// confirm returns whether a change to name should be applied, showing the
+// change with display and asking the user if they have not already answered.
+func (m *InteractiveMutator) confirm(name string, mergeable bool, display func(Mutator) error) (bool, error) {
+	switch {
+	case m.quit:
+		return false, nil
+	case m.all:
+		return true, nil
+	}
+	if decision, ok := m.decision(name); ok {
+		return decision, nil
+	}
+	if err := display(m.display); err != nil {
+		return false, err
+	}
+	if m.diffOnly {
+		return false, nil
+	}
+	choices := "ynaqd"
+	if mergeable && m.merge != nil {
+		choices += "m"
+	}
+	choice, err := m.prompt(fmt.Sprintf("Apply %s", name), choices)
+	if err != nil {
+		return false, err
+	}
+	switch choice {
+	case 'y':
+		m.decisions[name] = true
+		return true, nil
+	case 'n':
+		m.decisions[name] = false
+		return false, nil
+	case 'a':
+		m.all = true
+		return true, nil
+	case 'q':
+		m.quit = true
+		return false, nil
+	case 'd':
+		m.diffOnly = true
+		return false, nil
+	case 'm':
+		m.decisions[name] = false
+		return false, m.merge(name)
+	default:
+		return false, fmt.Errorf("%c: unknown choice", choice)
+	}
+}
+
+// decision returns the user's earlier answer for name, if any. Declining a
+// change to a directory declines all changes to the targets inside it.
+func (m *InteractiveMutator) decision(name string) (bool, bool) {
+	if decision, ok := m.decisions[name]; ok {
+		return decision, true
+	}
+	for dir := filepath.Dir(name); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if decision, ok := m.decisions[dir]; ok && !decision {
+			return false, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,169 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &InteractiveMutator{}
+
+func TestInteractiveMutator(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		choices           string
+		expectedPrompts   []string
+		expectedApplied   []string
+		expectedDisplayed []string
+		expectedMerged    []string
+	}{
+		{
+			name:    "yes_and_no",
+			choices: "nynm",
+			expectedPrompts: []string{
+				"Apply /home/user/dir [y,n,a,q,d]",
+				"Apply /home/user/file [y,n,a,q,d,m]",
+				"Apply /home/user/exact/extra [y,n,a,q,d]",
+				"Apply /home/user/merge [y,n,a,q,d,m]",
+			},
+			expectedApplied: []string{
+				"install -m 644 /dev/null /home/user/file",
+				"chmod 600 /home/user/file",
+			},
+			expectedDisplayed: []string{
+				"mkdir -m 755 /home/user/dir",
+				"install -m 644 /dev/null /home/user/file",
+				"rm -rf /home/user/exact/extra",
+				"install -m 644 /dev/null /home/user/merge",
+			},
+			expectedMerged: []string{
+				"/home/user/merge",
+			},
+		},
+		{
+			name:    "all",
+			choices: "ya",
+			expectedPrompts: []string{
+				"Apply /home/user/dir [y,n,a,q,d]",
+				"Apply /home/user/dir/file [y,n,a,q,d,m]",
+			},
+			expectedApplied: []string{
+				"mkdir -m 755 /home/user/dir",
+				"install -m 644 /dev/null /home/user/dir/file",
+				"install -m 644 /dev/null /home/user/file",
+				"chmod 600 /home/user/file",
+				"rm -rf /home/user/exact/extra",
+				"install -m 644 /dev/null /home/user/merge",
+			},
+			expectedDisplayed: []string{
+				"mkdir -m 755 /home/user/dir",
+				"install -m 644 /dev/null /home/user/dir/file",
+			},
+		},
+		{
+			name:    "quit",
+			choices: "yq",
+			expectedPrompts: []string{
+				"Apply /home/user/dir [y,n,a,q,d]",
+				"Apply /home/user/dir/file [y,n,a,q,d,m]",
+			},
+			expectedApplied: []string{
+				"mkdir -m 755 /home/user/dir",
+			},
+			expectedDisplayed: []string{
+				"mkdir -m 755 /home/user/dir",
+				"install -m 644 /dev/null /home/user/dir/file",
+			},
+		},
+		{
+			name:    "diff_only",
+			choices: "d",
+			expectedPrompts: []string{
+				"Apply /home/user/dir [y,n,a,q,d]",
+			},
+			expectedDisplayed: []string{
+				"mkdir -m 755 /home/user/dir",
+				"install -m 644 /dev/null /home/user/dir/file",
+				"install -m 644 /dev/null /home/user/file",
+				"chmod 600 /home/user/file",
+				"rm -rf /home/user/exact/extra",
+				"install -m 644 /dev/null /home/user/merge",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var prompts []string
+			prompt := func(s, choices string) (byte, error) {
+				prompts = append(prompts, s+" ["+strings.Join(strings.Split(choices, ""), ",")+"]")
+				choice := tc.choices[0]
+				tc.choices = tc.choices[1:]
+				return choice, nil
+			}
+			var merged []string
+			merge := func(targetPath string) error {
+				merged = append(merged, targetPath)
+				return nil
+			}
+			applied := &strings.Builder{}
+			displayed := &strings.Builder{}
+			m := NewInteractiveMutator(NewVerboseMutator(applied, NullMutator{}, false, 0, nil), displayed, false, 0, nil, prompt, merge)
+
+			require.NoError(t, m.Mkdir("/home/user/dir", 0o755))
+			require.NoError(t, m.WriteFile("/home/user/dir/file", nil, 0o644, nil))
+			require.NoError(t, m.WriteFile("/home/user/file", nil, 0o644, nil))
+			require.NoError(t, m.Chmod("/home/user/file", 0o600))
+			require.NoError(t, m.RemoveAll("/home/user/exact/extra"))
+			require.NoError(t, m.WriteFile("/home/user/merge", nil, 0o644, nil))
+
+			assert.Equal(t, "", tc.choices)
+			assert.Equal(t, tc.expectedPrompts, prompts)
+			assert.Equal(t, tc.expectedApplied, actionLines(applied.String()))
+			assert.Equal(t, tc.expectedDisplayed, actionLines(displayed.String()))
+			assert.Equal(t, tc.expectedMerged, merged)
+		})
+	}
+}
+
+func TestInteractiveMutatorExactDir(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/exact_dir/file": "# contents of file\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	prompt := func(s, choices string) (byte, error) {
+		return 'n', nil
+	}
+	m := NewInteractiveMutator(NewFSMutator(fs), &strings.Builder{}, false, 0, nil, prompt, nil)
+	require.NoError(t, ts.Apply(fs, m, false, &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Umask:   0o22,
+	}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/dir",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+// actionLines returns the lines in s that are not part of diffs.
+func actionLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,231 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A JSONLogMutator wraps a Mutator and writes a JSON object describing each
+// operation it executes to a writer, one per line.
+type JSONLogMutator struct {
+	m  Mutator
+	e  *json.Encoder
+	fs vfs.FS
+}
+
+// A jsonLogEvent is a single operation logged by a JSONLogMutator.
+type jsonLogEvent struct {
+	Time      time.Time `json:"time"`
+	Op        string    `json:"op"`
+	Path      string    `json:"path,omitempty"`
+	NewPath   string    `json:"newPath,omitempty"`
+	OldMode   string    `json:"oldMode,omitempty"`
+	NewMode   string    `json:"newMode,omitempty"`
+	OldSHA256 string    `json:"oldSHA256,omitempty"`
+	NewSHA256 string    `json:"newSHA256,omitempty"`
+	OldTarget string    `json:"oldTarget,omitempty"`
+	NewTarget string    `json:"newTarget,omitempty"`
+	UID       *int      `json:"uid,omitempty"`
+	GID       *int      `json:"gid,omitempty"`
+	Command   string    `json:"command,omitempty"`
+	Duration  float64   `json:"duration"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// NewJSONLogMutator returns a new JSONLogMutator that writes to w and reads
+// the previous state of targets from fs.
+func NewJSONLogMutator(w io.Writer, m Mutator, fs vfs.FS) *JSONLogMutator {
+	return &JSONLogMutator{
+		m:  m,
+		e:  json.NewEncoder(w),
+		fs: fs,
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *JSONLogMutator) Chmod(name string, mode os.FileMode) error {
+	event := &jsonLogEvent{
+		Op:      "chmod",
+		Path:    name,
+		OldMode: m.mode(name),
+		NewMode: formatMode(mode),
+	}
+	return m.log(event, func() error {
+		return m.m.Chmod(name, mode)
+	})
+}
+
+// Chown implements Mutator.Chown.
+func (m *JSONLogMutator) Chown(name string, uid, gid int) error {
+	event := &jsonLogEvent{
+		Op:   "chown",
+		Path: name,
+	}
+	if uid != -1 {
+		event.UID = &uid
+	}
+	if gid != -1 {
+		event.GID = &gid
+	}
+	return m.log(event, func() error {
+		return m.m.Chown(name, uid, gid)
+	})
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *JSONLogMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *JSONLogMutator) Mkdir(name string, perm os.FileMode) error {
+	event := &jsonLogEvent{
+		Op:      "mkdir",
+		Path:    name,
+		NewMode: formatMode(perm),
+	}
+	return m.log(event, func() error {
+		return m.m.Mkdir(name, perm)
+	})
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *JSONLogMutator) RemoveAll(name string) error {
+	event := &jsonLogEvent{
+		Op:   "removeAll",
+		Path: name,
+	}
+	if info, err := m.fs.Lstat(name); err == nil {
+		event.OldMode = formatMode(info.Mode())
+		switch {
+		case info.Mode().IsRegular():
+			event.OldSHA256 = m.sha256(name)
+		case info.Mode()&os.ModeType == os.ModeSymlink:
+			event.OldTarget, _ = m.fs.Readlink(name)
+		}
+	}
+	return m.log(event, func() error {
+		return m.m.RemoveAll(name)
+	})
+}
+
+// Rename implements Mutator.Rename.
+func (m *JSONLogMutator) Rename(oldpath, newpath string) error {
+	event := &jsonLogEvent{
+		Op:      "rename",
+		Path:    oldpath,
+		NewPath: newpath,
+	}
+	return m.log(event, func() error {
+		return m.m.Rename(oldpath, newpath)
+	})
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *JSONLogMutator) RunCmd(cmd *exec.Cmd) error {
+	event := &jsonLogEvent{
+		Op:      "runCmd",
+		Path:    cmd.Dir,
+		Command: cmdString(cmd),
+	}
+	return m.log(event, func() error {
+		return m.m.RunCmd(cmd)
+	})
+}
+
+// Stat implements Mutator.Stat.
+func (m *JSONLogMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *JSONLogMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	event := &jsonLogEvent{
+		Op:        "writeFile",
+		Path:      name,
+		OldMode:   m.mode(name),
+		NewMode:   formatMode(perm),
+		NewSHA256: sha256Sum(data),
+	}
+	if event.OldMode != "" {
+		event.OldSHA256 = sha256Sum(currData)
+	}
+	return m.log(event, func() error {
+		return m.m.WriteFile(name, data, perm, currData)
+	})
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *JSONLogMutator) WriteSymlink(oldname, newname string) error {
+	event := &jsonLogEvent{
+		Op:        "writeSymlink",
+		Path:      newname,
+		NewTarget: oldname,
+	}
+	event.OldTarget, _ = m.fs.Readlink(newname)
+	return m.log(event, func() error {
+		return m.m.WriteSymlink(oldname, newname)
+	})
+}
+
+// log calls f and writes event, updated with f's duration and error.
+func (m *JSONLogMutator) log(event *jsonLogEvent, f func() error) error {
+	event.Time = time.Now()
+	err := f()
+	event.Duration = time.Since(event.Time).Seconds()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if encodeErr := m.e.Encode(event); encodeErr != nil && err == nil {
+		return encodeErr
+	}
+	return err
+}
+
+// mode returns the formatted mode of name, or the empty string if name does
+// not exist.
+func (m *JSONLogMutator) mode(name string) string {
+	info, err := m.fs.Lstat(name)
+	if err != nil {
+		return ""
+	}
+	return formatMode(info.Mode())
+}
+
+// sha256 returns the SHA256 sum of the contents of name, or the empty string
+// if name cannot be read.
+func (m *JSONLogMutator) sha256(name string) string {
+	data, err := m.fs.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	return sha256Sum(data)
+}
+
+// formatMode returns mode formatted as a string of octal permission bits,
+// prefixed with its type if it is not a regular file.
+func formatMode(mode os.FileMode) string {
+	perm := fmt.Sprintf("%04o", mode.Perm())
+	switch {
+	case mode.IsDir():
+		return "d" + perm
+	case mode&os.ModeType == os.ModeSymlink:
+		return "l" + perm
+	default:
+		return perm
+	}
+}
+
+// sha256Sum returns the hex-encoded SHA256 sum of data.
+func sha256Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
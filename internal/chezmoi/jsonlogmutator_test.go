@@ -0,0 +1,80 @@
+package chezmoi
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ Mutator = &JSONLogMutator{}
+
+func TestJSONLogMutator(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": &vfst.File{
+				Perm:     0o600,
+				Contents: []byte("old\n"),
+			},
+			".inputrc": &vfst.File{
+				Perm:     0o644,
+				Contents: []byte("removed\n"),
+			},
+			".link": &vfst.Symlink{Target: ".bashrc"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	sb := &strings.Builder{}
+	m := NewJSONLogMutator(sb, NewFSMutator(fs), fs)
+	require.NoError(t, m.WriteFile("/home/user/.bashrc", []byte("new\n"), 0o644, []byte("old\n")))
+	require.NoError(t, m.RemoveAll("/home/user/.inputrc"))
+	require.NoError(t, m.WriteSymlink(".inputrc", "/home/user/.link"))
+	require.NoError(t, m.Mkdir("/home/user/.config", 0o755))
+	assert.Error(t, m.Mkdir("/home/user/.config", 0o755))
+
+	var events []jsonLogEvent
+	s := bufio.NewScanner(strings.NewReader(sb.String()))
+	for s.Scan() {
+		var event jsonLogEvent
+		require.NoError(t, json.Unmarshal(s.Bytes(), &event))
+		assert.False(t, event.Time.IsZero())
+		assert.GreaterOrEqual(t, event.Duration, 0.0)
+		event.Time = time.Time{}
+		event.Duration = 0
+		events = append(events, event)
+	}
+	require.NoError(t, s.Err())
+	require.Len(t, events, 5)
+
+	assert.Equal(t, jsonLogEvent{
+		Op:        "writeFile",
+		Path:      "/home/user/.bashrc",
+		OldMode:   "0600",
+		NewMode:   "0644",
+		OldSHA256: sha256Sum([]byte("old\n")),
+		NewSHA256: sha256Sum([]byte("new\n")),
+	}, events[0])
+	assert.Equal(t, jsonLogEvent{
+		Op:        "removeAll",
+		Path:      "/home/user/.inputrc",
+		OldMode:   "0644",
+		OldSHA256: sha256Sum([]byte("removed\n")),
+	}, events[1])
+	assert.Equal(t, jsonLogEvent{
+		Op:        "writeSymlink",
+		Path:      "/home/user/.link",
+		OldTarget: ".bashrc",
+		NewTarget: ".inputrc",
+	}, events[2])
+	assert.Equal(t, "mkdir", events[3].Op)
+	assert.Equal(t, "0755", events[3].NewMode)
+	assert.Empty(t, events[3].Error)
+	assert.NotEmpty(t, events[4].Error)
+}
@@ -0,0 +1,205 @@
+package chezmoi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+var (
+	templateErrorRegexp     = regexp.MustCompile(`\Atemplate: .*?:(\d+):(?:\d+:)? ((?s).*)\z`)
+	undefinedFunctionRegexp = regexp.MustCompile(`\Afunction "(.*)" not defined\z`)
+)
+
+// A TemplateProblem is a problem in a template found by LintTemplate.
+type TemplateProblem struct {
+	Line    int
+	Message string
+}
+
+// A templateLinter finds problems in a parsed template.
+type templateLinter struct {
+	text     string
+	data     map[string]interface{}
+	problems []TemplateProblem
+}
+
+// LintTemplate parses text as a template called name with funcs and returns
+// any problems found, in order of line. Syntax errors and all references to
+// unknown functions are reported. If data is not nil then references to keys
+// that are missing from data are also reported.
+func LintTemplate(name, text string, funcs template.FuncMap, data map[string]interface{}) []TemplateProblem {
+	l := &templateLinter{
+		text: text,
+		data: data,
+	}
+
+	// text/template stops parsing at the first unknown function, so replace
+	// each unknown function with a placeholder and parse again to find the
+	// rest.
+	lintFuncs := make(template.FuncMap, len(funcs))
+	for key, value := range funcs {
+		lintFuncs[key] = value
+	}
+	var tmpl *template.Template
+	for {
+		var err error
+		tmpl, err = template.New(name).Funcs(lintFuncs).Parse(text)
+		if err == nil {
+			break
+		}
+		line, message := parseTemplateError(err)
+		if m := undefinedFunctionRegexp.FindStringSubmatch(message); m != nil {
+			if _, ok := lintFuncs[m[1]]; !ok {
+				l.problems = append(l.problems, TemplateProblem{
+					Line:    line,
+					Message: fmt.Sprintf("%s: unknown function", m[1]),
+				})
+				lintFuncs[m[1]] = func(...interface{}) interface{} { return nil }
+				continue
+			}
+		}
+		l.problems = append(l.problems, TemplateProblem{
+			Line:    line,
+			Message: message,
+		})
+		return l.sortedProblems()
+	}
+
+	if l.data != nil && tmpl.Tree != nil {
+		// Only the data of the top level template is known.
+		l.walk(tmpl.Tree.Root, true)
+	}
+	return l.sortedProblems()
+}
+
+// LintHardcodedValues returns a problem for each value of the template
+// variables in data named by names, or contained in maps named by names, that
+// appears in contents, which is not a template, in order of line. Values are
+// found as AutoTemplate would find them with options, so excluded variables
+// are not reported.
+func LintHardcodedValues(contents []byte, data map[string]interface{}, names []string, options AutoTemplateOptions) []TemplateProblem {
+	var variables []templateVariable
+	for _, variable := range extractVariables(nil, nil, data) {
+		if containsVariable(names, variable.name) {
+			variables = append(variables, variable)
+		}
+	}
+	_, substitutions := autoTemplateVariables(contents, variables, options)
+	var problems []TemplateProblem
+	reported := make(map[TemplateProblem]struct{})
+	for _, substitution := range substitutions {
+		problem := TemplateProblem{
+			Line:    substitution.Line,
+			Message: fmt.Sprintf("%s: hard-coded value of .%s", substitution.Value, substitution.Name),
+		}
+		if _, ok := reported[problem]; ok {
+			continue
+		}
+		reported[problem] = struct{}{}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// String returns a string representation of p.
+func (p TemplateProblem) String() string {
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// checkDataKeys reports a problem if the keys in fields are not in l.data.
+func (l *templateLinter) checkDataKeys(node parse.Node, fields []string) {
+	var value interface{} = l.data
+	for i, field := range fields {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if value, ok = m[field]; !ok {
+			l.problems = append(l.problems, TemplateProblem{
+				Line:    l.line(node),
+				Message: fmt.Sprintf(".%s: missing data key", strings.Join(fields[:i+1], ".")),
+			})
+			return
+		}
+	}
+}
+
+// line returns the line number of node.
+func (l *templateLinter) line(node parse.Node) int {
+	return 1 + strings.Count(l.text[:int(node.Position())], "\n")
+}
+
+// sortedProblems returns l's problems sorted by line.
+func (l *templateLinter) sortedProblems() []TemplateProblem {
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Line < l.problems[j].Line
+	})
+	return l.problems
+}
+
+// walk checks the data keys referenced in node and its children. dotIsData is
+// true if dot is the template's data.
+func (l *templateLinter) walk(node parse.Node, dotIsData bool) {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		l.walk(n.Pipe, dotIsData)
+	case *parse.ChainNode:
+		l.walk(n.Node, dotIsData)
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			l.walk(arg, dotIsData)
+		}
+	case *parse.FieldNode:
+		if dotIsData {
+			l.checkDataKeys(n, n.Ident)
+		}
+	case *parse.IfNode:
+		l.walk(n.Pipe, dotIsData)
+		l.walk(n.List, dotIsData)
+		l.walk(n.ElseList, dotIsData)
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			l.walk(child, dotIsData)
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			l.walk(cmd, dotIsData)
+		}
+	case *parse.RangeNode:
+		l.walk(n.Pipe, dotIsData)
+		l.walk(n.List, false)
+		l.walk(n.ElseList, dotIsData)
+	case *parse.TemplateNode:
+		l.walk(n.Pipe, dotIsData)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			l.checkDataKeys(n, n.Ident[1:])
+		}
+	case *parse.WithNode:
+		l.walk(n.Pipe, dotIsData)
+		l.walk(n.List, false)
+		l.walk(n.ElseList, dotIsData)
+	}
+}
+
+// parseTemplateError returns the line number and message of err, which was
+// returned by text/template.
+func parseTemplateError(err error) (int, string) {
+	m := templateErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0, err.Error()
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line, m[2]
+}
@@ -0,0 +1,127 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintTemplate(t *testing.T) {
+	funcs := template.FuncMap{
+		"upper": strings.ToUpper,
+	}
+	data := map[string]interface{}{
+		"email": "john.smith@company.com",
+		"git": map[string]interface{}{
+			"name": "John Smith",
+		},
+		"hosts": []interface{}{"alpha", "beta"},
+	}
+	for _, tc := range []struct {
+		name     string
+		text     string
+		data     map[string]interface{}
+		expected []TemplateProblem
+	}{
+		{
+			name: "ok",
+			text: "{{ .email | upper }}\n{{ .git.name }}\n{{ range .hosts }}{{ .missing }}{{ end }}\n",
+			data: data,
+		},
+		{
+			name: "syntax_error",
+			text: "line one\n{{ .email }}\n{{ end }}\n",
+			data: data,
+			expected: []TemplateProblem{
+				{Line: 3, Message: "unexpected {{end}}"},
+			},
+		},
+		{
+			name: "unknown_functions",
+			text: "{{ lower .email }}\n{{ .email | upper }}\n{{ title .git.name }}\n",
+			data: data,
+			expected: []TemplateProblem{
+				{Line: 1, Message: "lower: unknown function"},
+				{Line: 3, Message: "title: unknown function"},
+			},
+		},
+		{
+			name: "missing_data_keys",
+			text: "{{ .email }}\n{{ .git.email }}\n{{ with .git }}{{ $.editor }}{{ end }}\n",
+			data: data,
+			expected: []TemplateProblem{
+				{Line: 2, Message: ".git.email: missing data key"},
+				{Line: 3, Message: ".editor: missing data key"},
+			},
+		},
+		{
+			name: "no_data",
+			text: "{{ .email }}\n{{ .editor }}\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LintTemplate(tc.name, tc.text, funcs, tc.data))
+		})
+	}
+}
+
+func TestLintHardcodedValues(t *testing.T) {
+	data := map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"fullHostname": "host.example.com",
+			"homedir":      "/home/user",
+			"hostname":     "host",
+			"os":           "linux",
+			"username":     "user",
+		},
+		"email": "john@example.com",
+	}
+	names := []string{"chezmoi.fullHostname", "chezmoi.homedir", "chezmoi.hostname", "chezmoi.username"}
+	for _, tc := range []struct {
+		name     string
+		contents string
+		options  AutoTemplateOptions
+		expected []TemplateProblem
+	}{
+		{
+			name:     "none",
+			contents: "export EDITOR=vi\nexport GOOS=linux\nexport EMAIL=john@example.com\n",
+		},
+		{
+			name:     "homedir",
+			contents: "export EDITOR=vi\nexport PATH=/home/user/bin:/home/user/.local/bin\n",
+			expected: []TemplateProblem{
+				{Line: 2, Message: "/home/user: hard-coded value of .chezmoi.homedir"},
+			},
+		},
+		{
+			name:     "hostname_and_username",
+			contents: "Host host.example.com\n\tUser user\nHost host\n",
+			expected: []TemplateProblem{
+				{Line: 1, Message: "host.example.com: hard-coded value of .chezmoi.fullHostname"},
+				{Line: 2, Message: "user: hard-coded value of .chezmoi.username"},
+				{Line: 3, Message: "host: hard-coded value of .chezmoi.hostname"},
+			},
+		},
+		{
+			name:     "words_only",
+			contents: "username = superuser\n",
+		},
+		{
+			name:     "exclude",
+			contents: "/home/user\nuser\n",
+			options: AutoTemplateOptions{
+				Exclude: []string{"chezmoi.username"},
+			},
+			expected: []TemplateProblem{
+				{Line: 1, Message: "/home/user: hard-coded value of .chezmoi.homedir"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LintHardcodedValues([]byte(tc.contents), data, names, tc.options))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package chezmoi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/diff"
+)
+
+// A MergeStrategy determines how Merge3 resolves conflicts.
+type MergeStrategy string
+
+// Merge strategies.
+const (
+	MergeStrategyConflict MergeStrategy = ""       // Write conflict markers.
+	MergeStrategyOurs     MergeStrategy = "ours"   // Use our changes.
+	MergeStrategyTheirs   MergeStrategy = "theirs" // Use their changes.
+	MergeStrategyUnion    MergeStrategy = "union"  // Use our changes followed by their changes.
+)
+
+// MergeOptions contains options for Merge3.
+type MergeOptions struct {
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	Strategy    MergeStrategy
+}
+
+// ParseMergeStrategy parses s as a MergeStrategy.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch strategy := MergeStrategy(s); strategy {
+	case MergeStrategyConflict, MergeStrategyOurs, MergeStrategyTheirs, MergeStrategyUnion:
+		return strategy, nil
+	default:
+		return MergeStrategyConflict, fmt.Errorf("%s: unknown merge strategy", s)
+	}
+}
+
+// Merge3 performs a line-based three-way merge of the changes from base to
+// ours and from base to theirs, like diff3. Changes made on only one side are
+// applied. Conflicting changes are resolved according to options.Strategy. It
+// returns the merged data and the number of conflicts, whether resolved or not.
+func Merge3(base, ours, theirs []byte, options MergeOptions) ([]byte, int) {
+	baseLines := splitLinesKeepEnds(base)
+	oursLines := splitLinesKeepEnds(ours)
+	theirsLines := splitLinesKeepEnds(theirs)
+	oursMatches := matchLines(baseLines, oursLines)
+	theirsMatches := matchLines(baseLines, theirsLines)
+
+	var merged bytes.Buffer
+	conflicts := 0
+	b, o, t := 0, 0, 0
+	for b < len(baseLines) || o < len(oursLines) || t < len(theirsLines) {
+		// Copy lines that are unchanged on both sides.
+		if b < len(baseLines) && oursMatches[b] == o && theirsMatches[b] == t {
+			merged.WriteString(baseLines[b])
+			b, o, t = b+1, o+1, t+1
+			continue
+		}
+
+		// Find the next base line that is unchanged on both sides, which ends
+		// the current chunk.
+		nextB, nextO, nextT := b, len(oursLines), len(theirsLines)
+		for ; nextB < len(baseLines); nextB++ {
+			if oursMatches[nextB] != -1 && theirsMatches[nextB] != -1 {
+				nextO, nextT = oursMatches[nextB], theirsMatches[nextB]
+				break
+			}
+		}
+		baseChunk := baseLines[b:nextB]
+		oursChunk := oursLines[o:nextO]
+		theirsChunk := theirsLines[t:nextT]
+		b, o, t = nextB, nextO, nextT
+
+		switch {
+		case equalLines(baseChunk, oursChunk):
+			writeLines(&merged, theirsChunk)
+		case equalLines(baseChunk, theirsChunk) || equalLines(oursChunk, theirsChunk):
+			writeLines(&merged, oursChunk)
+		default:
+			conflicts++
+			switch options.Strategy {
+			case MergeStrategyOurs:
+				writeLines(&merged, oursChunk)
+			case MergeStrategyTheirs:
+				writeLines(&merged, theirsChunk)
+			case MergeStrategyUnion:
+				writeLines(&merged, oursChunk)
+				writeLines(&merged, theirsChunk)
+			default:
+				writeConflictMarker(&merged, "<<<<<<<", options.OursLabel)
+				writeLines(&merged, oursChunk)
+				writeConflictMarker(&merged, "|||||||", options.BaseLabel)
+				writeLines(&merged, baseChunk)
+				writeConflictMarker(&merged, "=======", "")
+				writeLines(&merged, theirsChunk)
+				writeConflictMarker(&merged, ">>>>>>>", options.TheirsLabel)
+			}
+		}
+	}
+	return merged.Bytes(), conflicts
+}
+
+// equalLines returns if a and b are equal.
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLines returns, for each line in a, the index of the matching line in b,
+// or -1 if the line was removed.
+func matchLines(a, b []string) []int {
+	matches := make([]int, len(a))
+	for i := range matches {
+		matches[i] = -1
+	}
+	editScript := diff.Myers(context.Background(), diff.Strings(a, b))
+	for _, r := range editScript.IndexRanges {
+		if r.IsInsert() || r.IsDelete() {
+			continue
+		}
+		for i := 0; i < r.HighA-r.LowA; i++ {
+			matches[r.LowA+i] = r.LowB + i
+		}
+	}
+	return matches
+}
+
+// splitLinesKeepEnds splits data into lines, including each line's newline.
+func splitLinesKeepEnds(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeConflictMarker writes a conflict marker with an optional label to b,
+// ensuring that it starts on a new line.
+func writeConflictMarker(b *bytes.Buffer, marker, label string) {
+	if b.Len() > 0 && b.Bytes()[b.Len()-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	b.WriteString(marker)
+	if label != "" {
+		b.WriteByte(' ')
+		b.WriteString(label)
+	}
+	b.WriteByte('\n')
+}
+
+// writeLines writes lines to b.
+func writeLines(b *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
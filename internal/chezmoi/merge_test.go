@@ -0,0 +1,139 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge3(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		strategy      MergeStrategy
+		wantMerged    string
+		wantConflicts int
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:       "unchanged",
+			base:       "a\nb\nc\n",
+			ours:       "a\nb\nc\n",
+			theirs:     "a\nb\nc\n",
+			wantMerged: "a\nb\nc\n",
+		},
+		{
+			name:       "ours_changed",
+			base:       "a\nb\nc\n",
+			ours:       "a\nB\nc\n",
+			theirs:     "a\nb\nc\n",
+			wantMerged: "a\nB\nc\n",
+		},
+		{
+			name:       "theirs_changed",
+			base:       "a\nb\nc\n",
+			ours:       "a\nb\nc\n",
+			theirs:     "a\nb\nC\nd\n",
+			wantMerged: "a\nb\nC\nd\n",
+		},
+		{
+			name:       "both_changed_different_lines",
+			base:       "a\nb\nc\nd\ne\n",
+			ours:       "A\nb\nc\nd\ne\n",
+			theirs:     "a\nb\nc\nd\nE\n",
+			wantMerged: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:       "both_changed_identically",
+			base:       "a\nb\nc\n",
+			ours:       "a\nB\nc\n",
+			theirs:     "a\nB\nc\n",
+			wantMerged: "a\nB\nc\n",
+		},
+		{
+			name:       "theirs_deleted",
+			base:       "a\nb\nc\n",
+			ours:       "A\nb\nc\n",
+			theirs:     "a\nb\n",
+			wantMerged: "A\nb\n",
+		},
+		{
+			name:          "conflict",
+			base:          "a\nb\nc\n",
+			ours:          "a\nB1\nc\n",
+			theirs:        "a\nB2\nc\n",
+			wantMerged:    "a\n<<<<<<< ours\nB1\n||||||| base\nb\n=======\nB2\n>>>>>>> theirs\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict_no_final_newline",
+			base:          "a\nb",
+			ours:          "a\nB1",
+			theirs:        "a\nB2",
+			wantMerged:    "a\n<<<<<<< ours\nB1\n||||||| base\nb\n=======\nB2\n>>>>>>> theirs\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict_ours",
+			base:          "a\nb\nc\n",
+			ours:          "a\nB1\nc\n",
+			theirs:        "a\nB2\nc\n",
+			strategy:      MergeStrategyOurs,
+			wantMerged:    "a\nB1\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict_theirs",
+			base:          "a\nb\nc\n",
+			ours:          "a\nB1\nc\n",
+			theirs:        "a\nB2\nc\n",
+			strategy:      MergeStrategyTheirs,
+			wantMerged:    "a\nB2\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflict_union",
+			base:          "a\nb\nc\n",
+			ours:          "a\nB1\nc\n",
+			theirs:        "a\nB2\nc\n",
+			strategy:      MergeStrategyUnion,
+			wantMerged:    "a\nB1\nB2\nc\n",
+			wantConflicts: 1,
+		},
+		{
+			name:          "conflicting_inserts",
+			base:          "a\n",
+			ours:          "a\nb\n",
+			theirs:        "a\nc\n",
+			strategy:      MergeStrategyUnion,
+			wantMerged:    "a\nb\nc\n",
+			wantConflicts: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotMerged, gotConflicts := Merge3([]byte(tc.base), []byte(tc.ours), []byte(tc.theirs), MergeOptions{
+				OursLabel:   "ours",
+				BaseLabel:   "base",
+				TheirsLabel: "theirs",
+				Strategy:    tc.strategy,
+			})
+			assert.Equal(t, tc.wantMerged, string(gotMerged))
+			assert.Equal(t, tc.wantConflicts, gotConflicts)
+		})
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	for _, s := range []string{"", "ours", "theirs", "union"} {
+		strategy, err := ParseMergeStrategy(s)
+		require.NoError(t, err)
+		assert.Equal(t, MergeStrategy(s), strategy)
+	}
+	_, err := ParseMergeStrategy("unknown")
+	assert.Error(t, err)
+}
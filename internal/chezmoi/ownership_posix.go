@@ -0,0 +1,67 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// ensureOwnership ensures that targetPath in fs has ownership.
+func ensureOwnership(fs vfs.Stater, mutator Mutator, targetPath string, ownership Ownership) error {
+	if ownership == (Ownership{}) {
+		return nil
+	}
+	uid, gid, err := lookupOwnership(ownership)
+	if err != nil {
+		return err
+	}
+	info, err := fs.Stat(targetPath)
+	switch {
+	case err == nil:
+		if statT, ok := info.Sys().(*syscall.Stat_t); ok {
+			if (uid == -1 || uint32(uid) == statT.Uid) && (gid == -1 || uint32(gid) == statT.Gid) {
+				return nil
+			}
+		}
+	case os.IsNotExist(err):
+	default:
+		return err
+	}
+	return mutator.Chown(targetPath, uid, gid)
+}
+
+// lookupOwnership returns the uid and gid of ownership. Unmanaged values are
+// returned as -1, as understood by chown(2).
+func lookupOwnership(ownership Ownership) (int, int, error) {
+	uid, gid := -1, -1
+	if ownership.Owner != "" {
+		var err error
+		if uid, err = strconv.Atoi(ownership.Owner); err != nil {
+			u, err := user.Lookup(ownership.Owner)
+			if err != nil {
+				return 0, 0, err
+			}
+			if uid, err = strconv.Atoi(u.Uid); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	if ownership.Group != "" {
+		var err error
+		if gid, err = strconv.Atoi(ownership.Group); err != nil {
+			g, err := user.LookupGroup(ownership.Group)
+			if err != nil {
+				return 0, 0, err
+			}
+			if gid, err = strconv.Atoi(g.Gid); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+	return uid, gid, nil
+}
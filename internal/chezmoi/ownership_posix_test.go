@@ -0,0 +1,59 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetStateOwnership(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/etc/hosts": &vfst.File{
+			Perm:     0o644,
+			Contents: []byte("127.0.0.1 localhost\n"),
+		},
+		"/home/user/other": &vfst.File{
+			Perm:     0o644,
+			Contents: []byte("other"),
+		},
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiattributes": "" +
+				"etc/** owner=12345 # comment\n" +
+				"etc/hosts group=23456\n",
+			"etc/hosts": "127.0.0.1 localhost\n",
+			"other":     "other",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithDestDir("/home/user"),
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+	)
+	require.NoError(t, ts.Populate(fs, nil))
+
+	etc := ts.Entries["etc"].(*Dir)
+	assert.Equal(t, Ownership{}, etc.Ownership)
+	assert.Equal(t, Ownership{Owner: "12345", Group: "23456"}, etc.Entries["hosts"].(*File).Ownership)
+	assert.Equal(t, Ownership{}, ts.Entries["other"].(*File).Ownership)
+
+	sb := &strings.Builder{}
+	applyOptions := &ApplyOptions{
+		DestDir: ts.DestDir,
+		Ignore:  ts.TargetIgnore.Match,
+		Umask:   0o22,
+	}
+	require.NoError(t, ts.Apply(fs, NewVerboseMutator(sb, NullMutator{}, false, 0, nil), false, applyOptions))
+	assert.Equal(t, "chown 12345:23456 /home/user/etc/hosts\n", sb.String())
+
+	// Ownership drift alone is reported as a mutation, as used by verify.
+	anyMutator := NewAnyMutator(NullMutator{})
+	require.NoError(t, etc.Entries["hosts"].Apply(fs, anyMutator, false, applyOptions))
+	assert.True(t, anyMutator.Mutated())
+}
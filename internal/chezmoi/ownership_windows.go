@@ -0,0 +1,18 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"fmt"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// ensureOwnership returns an error if ownership is set, as ownership is not
+// supported on Windows.
+func ensureOwnership(fs vfs.Stater, mutator Mutator, targetPath string, ownership Ownership) error {
+	if ownership == (Ownership{}) {
+		return nil
+	}
+	return fmt.Errorf("%s: ownership is not supported on windows", targetPath)
+}
@@ -0,0 +1,285 @@
+package chezmoi
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	redactorMinSecretLength = 4
+	redactorMask            = "<redacted>"
+)
+
+// redactorMetadataKeys are the lowercase names of the fields in the output of
+// password managers, for example 1Password, Bitwarden, KeePassXC, and
+// LastPass, that describe an item rather than holding its secrets. Their
+// values, like "password" or "username", are labels that also appear in
+// unrelated output, so they are not recorded as secrets.
+var redactorMetadataKeys = map[string]struct{}{
+	"ainfo":             {},
+	"changeruuid":       {},
+	"collectionids":     {},
+	"createdat":         {},
+	"designation":       {},
+	"favorite":          {},
+	"folderid":          {},
+	"group":             {},
+	"id":                {},
+	"itemversion":       {},
+	"k":                 {},
+	"l":                 {},
+	"last_modified_gmt": {},
+	"last_touch":        {},
+	"match":             {},
+	"n":                 {},
+	"name":              {},
+	"organizationid":    {},
+	"ps":                {},
+	"revisiondate":      {},
+	"t":                 {},
+	"tags":              {},
+	"templateuuid":      {},
+	"title":             {},
+	"trashed":           {},
+	"type":              {},
+	"updatedat":         {},
+	"uri":               {},
+	"uris":              {},
+	"url":               {},
+	"urls":              {},
+	"uuid":              {},
+	"vaultuuid":         {},
+}
+
+// A Redactor records secret values and masks them in output. A nil *Redactor
+// does not mask anything. It is safe for concurrent use.
+type Redactor struct {
+	mutex    sync.Mutex
+	secrets  map[string]struct{}
+	replacer *strings.Replacer
+}
+
+// NewRedactor returns a new Redactor.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets: make(map[string]struct{}),
+	}
+}
+
+// Add records secret. Secrets shorter than four bytes are ignored as masking
+// them would mask too much unrelated output.
+func (r *Redactor) Add(secret string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.add(secret)
+	if trimmedSecret := strings.TrimSpace(secret); trimmedSecret != secret {
+		r.add(trimmedSecret)
+	}
+}
+
+// AddValue records all the strings in value, which may be a string or
+// arbitrarily nested maps and slices, as secrets. Map keys are not recorded.
+func (r *Redactor) AddValue(value interface{}) {
+	r.addValue(reflect.ValueOf(value), false)
+}
+
+// Redact returns a copy of data with all secrets masked.
+func (r *Redactor) Redact(data []byte) []byte {
+	replacer := r.getReplacer()
+	if replacer == nil {
+		return data
+	}
+	b := &bytes.Buffer{}
+	_, _ = replacer.WriteString(b, string(data))
+	return b.Bytes()
+}
+
+// RedactString returns s with all secrets masked.
+func (r *Redactor) RedactString(s string) string {
+	replacer := r.getReplacer()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// RedactValue returns a copy of value, which may contain arbitrarily nested
+// maps, slices, and structs, with all secrets in strings masked.
+func (r *Redactor) RedactValue(value interface{}) interface{} {
+	if r.getReplacer() == nil || value == nil {
+		return value
+	}
+	return r.redactValue(reflect.ValueOf(value)).Interface()
+}
+
+// WrapFunc returns a function with the same type as f that records the first
+// value returned by f as a secret.
+func (r *Redactor) WrapFunc(f interface{}) interface{} {
+	return r.wrapFunc(f, false)
+}
+
+// WrapItemFunc returns a function with the same type as f that records the
+// first value returned by f, which contains password manager items, as a
+// secret. The values of fields that describe the items, like their names,
+// titles, and types, are not recorded.
+func (r *Redactor) WrapItemFunc(f interface{}) interface{} {
+	return r.wrapFunc(f, true)
+}
+
+func (r *Redactor) wrapFunc(f interface{}, skipMetadata bool) interface{} {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func || fv.Type().NumOut() == 0 {
+		return f
+	}
+	return reflect.MakeFunc(fv.Type(), func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if fv.Type().IsVariadic() {
+			results = fv.CallSlice(args)
+		} else {
+			results = fv.Call(args)
+		}
+		r.addValue(results[0], skipMetadata)
+		return results
+	}).Interface()
+}
+
+// add records secret. The caller must hold r.mutex.
+func (r *Redactor) add(secret string) {
+	if len(secret) < redactorMinSecretLength {
+		return
+	}
+	if _, ok := r.secrets[secret]; ok {
+		return
+	}
+	r.secrets[secret] = struct{}{}
+	r.replacer = nil
+}
+
+// addValue records all the strings in value. If skipMetadata is true then the
+// values of map keys in redactorMetadataKeys are skipped.
+func (r *Redactor) addValue(value reflect.Value, skipMetadata bool) {
+	switch value.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !value.IsNil() {
+			r.addValue(value.Elem(), skipMetadata)
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			if skipMetadata && isRedactorMetadataKey(key) {
+				continue
+			}
+			r.addValue(value.MapIndex(key), skipMetadata)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			r.Add(string(value.Bytes()))
+			return
+		}
+		for i := 0; i < value.Len(); i++ {
+			r.addValue(value.Index(i), skipMetadata)
+		}
+	case reflect.String:
+		r.Add(value.String())
+	}
+}
+
+// isRedactorMetadataKey returns true if key is in redactorMetadataKeys.
+func isRedactorMetadataKey(key reflect.Value) bool {
+	if key.Kind() == reflect.Interface {
+		key = key.Elem()
+	}
+	if key.Kind() != reflect.String {
+		return false
+	}
+	_, ok := redactorMetadataKeys[strings.ToLower(key.String())]
+	return ok
+}
+
+func (r *Redactor) redactValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.New(value.Type()).Elem()
+		result.Set(r.redactValue(value.Elem()))
+		return result
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.New(value.Type().Elem())
+		result.Elem().Set(r.redactValue(value.Elem()))
+		return result
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		result := reflect.MakeMapWithSize(value.Type(), value.Len())
+		for _, key := range value.MapKeys() {
+			result.SetMapIndex(key, r.redactValue(value.MapIndex(key)))
+		}
+		return result
+	case reflect.Slice:
+		if value.IsNil() || value.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(r.redactValue(value.Index(i)))
+		}
+		return result
+	case reflect.String:
+		result := reflect.New(value.Type()).Elem()
+		result.SetString(r.RedactString(value.String()))
+		return result
+	case reflect.Struct:
+		result := reflect.New(value.Type()).Elem()
+		result.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if field := result.Field(i); field.CanSet() {
+				field.Set(r.redactValue(value.Field(i)))
+			}
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// getReplacer returns a replacer that masks all secrets, or nil if there are
+// no secrets.
+func (r *Redactor) getReplacer() *strings.Replacer {
+	if r == nil {
+		return nil
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.secrets) == 0 {
+		return nil
+	}
+	if r.replacer == nil {
+		// strings.Replacer tries replacements in argument order, so add the
+		// longest secrets first so that they are masked completely when one
+		// secret contains another.
+		secrets := make([]string, 0, len(r.secrets))
+		for secret := range r.secrets {
+			secrets = append(secrets, secret)
+		}
+		sort.Slice(secrets, func(i, j int) bool {
+			if len(secrets[i]) != len(secrets[j]) {
+				return len(secrets[i]) > len(secrets[j])
+			}
+			return secrets[i] < secrets[j]
+		})
+		oldnew := make([]string, 0, 2*len(secrets))
+		for _, secret := range secrets {
+			oldnew = append(oldnew, secret, redactorMask)
+		}
+		r.replacer = strings.NewReplacer(oldnew...)
+	}
+	return r.replacer
+}
@@ -0,0 +1,119 @@
+package chezmoi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor()
+	r.Add("secret")
+	r.Add("abc")
+	r.Add("supersecret\n")
+	r.AddValue(map[string]interface{}{
+		"password": "hunter22",
+		"list":     []interface{}{"listsecret", 1},
+	})
+	for _, tc := range []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: ""},
+		{s: "abc", want: "abc"},
+		{s: "password secret", want: "password <redacted>"},
+		{s: "password supersecret", want: "password <redacted>"},
+		{s: "supersecret\nsecret", want: "<redacted><redacted>"},
+		{s: "hunter22 listsecret", want: "<redacted> <redacted>"},
+	} {
+		assert.Equal(t, tc.want, r.RedactString(tc.s))
+		assert.Equal(t, tc.want, string(r.Redact([]byte(tc.s))))
+	}
+}
+
+func TestRedactorNil(t *testing.T) {
+	var r *Redactor
+	assert.Equal(t, "secret", r.RedactString("secret"))
+	assert.Equal(t, []byte("secret"), r.Redact([]byte("secret")))
+	value := map[string]interface{}{"key": "secret"}
+	assert.Equal(t, value, r.RedactValue(value))
+}
+
+func TestRedactorRedactValue(t *testing.T) {
+	type entry struct {
+		Name     string `json:"name"`
+		Contents string `json:"contents"`
+	}
+	r := NewRedactor()
+	r.Add("secret")
+	value := []interface{}{
+		&entry{Name: "file", Contents: "password secret"},
+		map[string]interface{}{"key": "secret"},
+	}
+	assert.Equal(t, []interface{}{
+		&entry{Name: "file", Contents: "password <redacted>"},
+		map[string]interface{}{"key": "<redacted>"},
+	}, r.RedactValue(value))
+	assert.Equal(t, "password secret", value[0].(*entry).Contents)
+}
+
+func TestRedactorWrapFunc(t *testing.T) {
+	r := NewRedactor()
+	f := r.WrapFunc(func(args ...string) string {
+		return strings.Join(args, "")
+	}).(func(...string) string)
+	assert.Equal(t, "secret", f("sec", "ret"))
+	assert.Equal(t, "<redacted>", r.RedactString("secret"))
+
+	g := r.WrapFunc(func() map[string]interface{} {
+		return map[string]interface{}{
+			"name": "secretname",
+		}
+	}).(func() map[string]interface{})
+	g()
+	assert.Equal(t, "<redacted>", r.RedactString("secretname"))
+}
+
+func TestRedactorWrapItemFunc(t *testing.T) {
+	r := NewRedactor()
+	f := r.WrapItemFunc(func() map[string]interface{} {
+		return map[string]interface{}{
+			"uuid": "wxcplh5udshnonkzg2n4qx262y",
+			"details": map[string]interface{}{
+				"fields": []interface{}{
+					map[string]interface{}{
+						"designation": "username",
+						"name":        "username",
+						"type":        "T",
+						"value":       "exampleuser",
+					},
+					map[string]interface{}{
+						"designation": "password",
+						"name":        "password",
+						"type":        "P",
+						"value":       "examplepassword",
+					},
+				},
+				"notesPlain": "examplenotes",
+			},
+			"overview": map[string]interface{}{
+				"title": "login",
+				"url":   "https://example.com/",
+			},
+		}
+	}).(func() map[string]interface{})
+	f()
+	for _, tc := range []struct {
+		s    string
+		want string
+	}{
+		{s: "username exampleuser", want: "username <redacted>"},
+		{s: "password examplepassword", want: "password <redacted>"},
+		{s: "notes examplenotes", want: "notes <redacted>"},
+		{s: "login https://example.com/", want: "login https://example.com/"},
+		{s: "type wxcplh5udshnonkzg2n4qx262y", want: "type wxcplh5udshnonkzg2n4qx262y"},
+	} {
+		assert.Equal(t, tc.want, r.RedactString(tc.s))
+	}
+}
@@ -0,0 +1,325 @@
+package chezmoi
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/diff"
+)
+
+const branchTakenFuncName = "_chezmoiBranchTaken"
+
+// A ReverseTemplateHunk is a change to the output of a template that cannot be
+// mapped back onto the template's literal text.
+type ReverseTemplateHunk struct {
+	Line     int // Line in the template's output, starting at 1.
+	OldLines []string
+	NewLines []string
+}
+
+// ReverseTemplateOptions contains options for TargetState.ReverseTemplate.
+type ReverseTemplateOptions struct {
+	// AutoTemplateData, if not nil, is used to replace values in new text
+	// with the template variables that contain them.
+	AutoTemplateData map[string]interface{}
+	// AutoTemplateOptions are the options used with AutoTemplateData.
+	AutoTemplateOptions AutoTemplateOptions
+}
+
+// A literalLinesPair is a diff.Pair that matches template source lines that
+// contain only literal text with identical lines of the template's output.
+type literalLinesPair struct {
+	sourceLines   []string
+	isLiteralLine []bool
+	outputLines   []string
+}
+
+func (p *literalLinesPair) LenA() int { return len(p.sourceLines) }
+func (p *literalLinesPair) LenB() int { return len(p.outputLines) }
+func (p *literalLinesPair) Equal(ai, bi int) bool {
+	return p.isLiteralLine[ai] && p.sourceLines[ai] == p.outputLines[bi]
+}
+
+// ReverseTemplate applies the changes from output, the output of the template
+// source, to newOutput, to source. Changes to lines that come from the literal
+// text of source are applied to that text. Changes that cannot be mapped onto
+// the literal text of source are not applied and are returned instead. source
+// is executed with ts's template data to find which branches of its
+// conditionals produced output.
+func (ts *TargetState) ReverseTemplate(name string, source, output, newOutput []byte, options ReverseTemplateOptions) ([]byte, []ReverseTemplateHunk, error) {
+	isLiteralLine, err := ts.templateLiteralLines(name, string(source))
+	if err != nil {
+		return nil, nil, err
+	}
+	sourceLines := splitLinesKeepEnds(source)
+	outputLines := splitLinesKeepEnds(output)
+	newOutputLines := splitLinesKeepEnds(newOutput)
+
+	// Map each output line to the literal source line that produced it, if
+	// any.
+	outputSourceLines := make([]int, len(outputLines))
+	for i := range outputSourceLines {
+		outputSourceLines[i] = -1
+	}
+	for _, r := range diff.Myers(context.Background(), &literalLinesPair{
+		sourceLines:   sourceLines,
+		isLiteralLine: isLiteralLine,
+		outputLines:   outputLines,
+	}).IndexRanges {
+		if r.IsInsert() || r.IsDelete() {
+			continue
+		}
+		for i := 0; i < r.HighB-r.LowB; i++ {
+			outputSourceLines[r.LowB+i] = r.LowA + i
+		}
+	}
+
+	var result []byte
+	var unresolved []ReverseTemplateHunk
+	sourceIndex := 0
+	for _, hunk := range diffHunks(outputLines, newOutputLines) {
+		low, high, ok := hunk.sourceRange(outputSourceLines, len(sourceLines))
+		if !ok || low < sourceIndex {
+			unresolved = append(unresolved, ReverseTemplateHunk{
+				Line:     hunk.lowA + 1,
+				OldLines: outputLines[hunk.lowA:hunk.highA],
+				NewLines: newOutputLines[hunk.lowB:hunk.highB],
+			})
+			continue
+		}
+		for _, line := range sourceLines[sourceIndex:low] {
+			result = append(result, line...)
+		}
+		var newText []byte
+		for _, line := range newOutputLines[hunk.lowB:hunk.highB] {
+			newText = append(newText, line...)
+		}
+		if options.AutoTemplateData != nil {
+			newText, _ = AutoTemplate(newText, options.AutoTemplateData, options.AutoTemplateOptions)
+		} else {
+			newText = templateEscape(newText)
+		}
+		result = append(result, newText...)
+		sourceIndex = high
+	}
+	for _, line := range sourceLines[sourceIndex:] {
+		result = append(result, line...)
+	}
+	return result, unresolved, nil
+}
+
+// A diffHunk is a change that replaces a[lowA:highA] with b[lowB:highB].
+type diffHunk struct {
+	lowA, highA int
+	lowB, highB int
+}
+
+// diffHunks returns the changes from a to b.
+func diffHunks(a, b []string) []diffHunk {
+	var hunks []diffHunk
+	var hunk *diffHunk
+	for _, r := range diff.Myers(context.Background(), diff.Strings(a, b)).IndexRanges {
+		if !r.IsInsert() && !r.IsDelete() {
+			hunk = nil
+			continue
+		}
+		if hunk == nil {
+			hunks = append(hunks, diffHunk{
+				lowA:  r.LowA,
+				highA: r.HighA,
+				lowB:  r.LowB,
+				highB: r.HighB,
+			})
+			hunk = &hunks[len(hunks)-1]
+			continue
+		}
+		// Adjacent insertions and deletions do not always have consistent
+		// indexes, so extend the hunk to cover both.
+		if r.HighA > hunk.highA {
+			hunk.highA = r.HighA
+		}
+		if r.HighB > hunk.highB {
+			hunk.highB = r.HighB
+		}
+	}
+	return hunks
+}
+
+// sourceRange returns the range of source lines that produced the output lines
+// changed by h, given the source line that produced each output line and the
+// number of source lines.
+func (h *diffHunk) sourceRange(outputSourceLines []int, sourceLines int) (int, int, bool) {
+	if h.lowA == h.highA {
+		// The hunk only inserts lines, so insert them at the start or end of
+		// the source, after the source of the preceding output line, or before
+		// the source of the following output line.
+		switch {
+		case h.lowA == 0:
+			return 0, 0, true
+		case h.lowA == len(outputSourceLines):
+			return sourceLines, sourceLines, true
+		case outputSourceLines[h.lowA-1] != -1:
+			sourceIndex := outputSourceLines[h.lowA-1] + 1
+			return sourceIndex, sourceIndex, true
+		case h.lowA < len(outputSourceLines) && outputSourceLines[h.lowA] != -1:
+			sourceIndex := outputSourceLines[h.lowA]
+			return sourceIndex, sourceIndex, true
+		default:
+			return 0, 0, false
+		}
+	}
+	// Otherwise, every changed output line must come from consecutive literal
+	// source lines.
+	low := outputSourceLines[h.lowA]
+	if low == -1 {
+		return 0, 0, false
+	}
+	for i := h.lowA + 1; i < h.highA; i++ {
+		if outputSourceLines[i] != low+i-h.lowA {
+			return 0, 0, false
+		}
+	}
+	return low, low + h.highA - h.lowA, true
+}
+
+// templateLiteralLines returns, for each line of the template source, whether
+// the line consists only of literal text that is output exactly once when the
+// template is executed with ts's template data.
+func (ts *TargetState) templateLiteralLines(name, source string) ([]bool, error) {
+	// Record the branches of conditionals that run by adding an action that
+	// calls branchTakenFuncName to the start of each branch.
+	var branchTaken []bool
+	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Funcs(template.FuncMap{
+		branchTakenFuncName: func(index int) string {
+			branchTaken[index] = true
+			return ""
+		},
+	}).Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	for templateName, t := range ts.Templates {
+		if _, err := tmpl.AddParseTree(templateName, t.Tree); err != nil {
+			return nil, err
+		}
+	}
+	var branches []*parse.ListNode
+	if tmpl.Tree != nil {
+		branches = instrumentBranches(branches, tmpl.Tree.Root)
+	}
+	branchTaken = make([]bool, len(branches))
+	if err := tmpl.ExecuteTemplate(ioutil.Discard, name, ts.TemplateData); err != nil {
+		return nil, err
+	}
+	ranBranches := make(map[*parse.ListNode]bool, len(branches))
+	for i, branch := range branches {
+		ranBranches[branch] = branchTaken[i]
+	}
+
+	isLiteralByte := make([]bool, len(source))
+	if tmpl.Tree != nil {
+		markLiteralBytes(isLiteralByte, tmpl.Tree.Root, ranBranches)
+	}
+	sourceLines := splitLinesKeepEnds([]byte(source))
+	isLiteralLine := make([]bool, len(sourceLines))
+	offset := 0
+	for i, line := range sourceLines {
+		isLiteralLine[i] = true
+		for j := offset; j < offset+len(line); j++ {
+			if !isLiteralByte[j] {
+				isLiteralLine[i] = false
+				break
+			}
+		}
+		offset += len(line)
+	}
+	return isLiteralLine, nil
+}
+
+// instrumentBranches adds an action that records that the branch ran to the
+// start of each branch of the conditionals in list, outside range bodies, and
+// appends the branches to branches.
+func instrumentBranches(branches []*parse.ListNode, list *parse.ListNode) []*parse.ListNode {
+	if list == nil {
+		return branches
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.IfNode:
+			branches = instrumentBranch(branches, n.List)
+			branches = instrumentBranch(branches, n.ElseList)
+		case *parse.WithNode:
+			branches = instrumentBranch(branches, n.List)
+			branches = instrumentBranch(branches, n.ElseList)
+		}
+	}
+	return branches
+}
+
+// instrumentBranch adds an action that records that branch ran to the start of
+// branch, and appends it and its nested branches to branches.
+func instrumentBranch(branches []*parse.ListNode, branch *parse.ListNode) []*parse.ListNode {
+	if branch == nil {
+		return branches
+	}
+	branches = instrumentBranches(branches, branch)
+	index := len(branches)
+	branches = append(branches, branch)
+	branch.Nodes = append([]parse.Node{
+		&parse.ActionNode{
+			NodeType: parse.NodeAction,
+			Pipe: &parse.PipeNode{
+				NodeType: parse.NodePipe,
+				Cmds: []*parse.CommandNode{
+					{
+						NodeType: parse.NodeCommand,
+						Args: []parse.Node{
+							parse.NewIdentifier(branchTakenFuncName),
+							&parse.NumberNode{
+								NodeType: parse.NodeNumber,
+								IsInt:    true,
+								Int64:    int64(index),
+								Text:     strconv.Itoa(index),
+							},
+						},
+					},
+				},
+			},
+		},
+	}, branch.Nodes...)
+	return branches
+}
+
+// markLiteralBytes marks the bytes of the text nodes in list, and in the
+// branches of any conditionals in list that ran, as literal. Text in branches
+// that did not run is not marked as it did not produce the output, and text in
+// range bodies is not marked as it may be output multiple times.
+func markLiteralBytes(isLiteralByte []bool, list *parse.ListNode, ranBranches map[*parse.ListNode]bool) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			for i := int(n.Pos); i < int(n.Pos)+len(n.Text) && i < len(isLiteralByte); i++ {
+				isLiteralByte[i] = true
+			}
+		case *parse.IfNode:
+			markRanBranch(isLiteralByte, n.List, ranBranches)
+			markRanBranch(isLiteralByte, n.ElseList, ranBranches)
+		case *parse.WithNode:
+			markRanBranch(isLiteralByte, n.List, ranBranches)
+			markRanBranch(isLiteralByte, n.ElseList, ranBranches)
+		}
+	}
+}
+
+// markRanBranch marks the literal bytes of branch if it ran.
+func markRanBranch(isLiteralByte []bool, branch *parse.ListNode, ranBranches map[*parse.ListNode]bool) {
+	if ranBranches[branch] {
+		markLiteralBytes(isLiteralByte, branch, ranBranches)
+	}
+}
@@ -0,0 +1,156 @@
+package chezmoi
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"email": "user@example.com",
+		"home":  false,
+		"items": []interface{}{"a", "b"},
+		"name":  "User",
+		"work":  true,
+	}
+	for _, tc := range []struct {
+		name             string
+		source           string
+		newOutput        string
+		autoTemplateData map[string]interface{}
+		wantSource       string
+		wantUnresolved   []ReverseTemplateHunk
+	}{
+		{
+			name:       "unchanged",
+			source:     "[user]\n\tname = {{ .name }}\n",
+			newOutput:  "[user]\n\tname = User\n",
+			wantSource: "[user]\n\tname = {{ .name }}\n",
+		},
+		{
+			name:       "change_literal",
+			source:     "[core]\n\teditor = vi\n[user]\n\tname = {{ .name }}\n",
+			newOutput:  "[core]\n\teditor = vim\n[user]\n\tname = User\n",
+			wantSource: "[core]\n\teditor = vim\n[user]\n\tname = {{ .name }}\n",
+		},
+		{
+			name:       "insert_after_literal",
+			source:     "[user]\n\tname = {{ .name }}\n",
+			newOutput:  "[user]\n\temail = other@example.com\n\tname = User\n",
+			wantSource: "[user]\n\temail = other@example.com\n\tname = {{ .name }}\n",
+		},
+		{
+			name:       "insert_at_start",
+			source:     "{{ .name }}\n",
+			newOutput:  "# comment\nUser\n",
+			wantSource: "# comment\n{{ .name }}\n",
+		},
+		{
+			name:       "delete_literal",
+			source:     "a\nb\n{{ .name }}\n",
+			newOutput:  "a\nUser\n",
+			wantSource: "a\n{{ .name }}\n",
+		},
+		{
+			name:       "change_literal_in_conditional",
+			source:     "{{ if .work }}\nwork\n{{ else }}\nhome\n{{ end }}\n",
+			newOutput:  "\nWORK\n\n",
+			wantSource: "{{ if .work }}\nWORK\n{{ else }}\nhome\n{{ end }}\n",
+		},
+		{
+			name:       "change_literal_in_branch_that_ran",
+			source:     "{{ if .home }}\neditor = vim\n{{ else }}\neditor = vim\n{{ end }}\n",
+			newOutput:  "\neditor = emacs\n\n",
+			wantSource: "{{ if .home }}\neditor = vim\n{{ else }}\neditor = emacs\n{{ end }}\n",
+		},
+		{
+			name:       "change_literal_in_nested_conditional",
+			source:     "{{ with .name }}\n{{ if $.home }}\nhome\n{{ else }}\nwork\n{{ end }}\n{{ end }}\n",
+			newOutput:  "\n\nWORK\n\n\n",
+			wantSource: "{{ with .name }}\n{{ if $.home }}\nhome\n{{ else }}\nWORK\n{{ end }}\n{{ end }}\n",
+		},
+		{
+			name:       "change_output_of_branch_that_did_not_run",
+			source:     "{{ if .home }}\nhome\n{{ end }}\n{{ .name }}\n",
+			newOutput:  "\nhome\n",
+			wantSource: "{{ if .home }}\nhome\n{{ end }}\n{{ .name }}\n",
+			wantUnresolved: []ReverseTemplateHunk{
+				{
+					Line:     2,
+					OldLines: []string{"User\n"},
+					NewLines: []string{"home\n"},
+				},
+			},
+		},
+		{
+			name:       "escape_delimiters",
+			source:     "a\n",
+			newOutput:  "a\n{{ b }}\n",
+			wantSource: "a\n{{ \"{{\" }} b {{ \"}}\" }}\n",
+		},
+		{
+			name:             "autotemplate",
+			source:           "[user]\n\tname = {{ .name }}\n",
+			newOutput:        "[user]\n\tname = User\n\temail = user@example.com\n",
+			autoTemplateData: data,
+			wantSource:       "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n",
+		},
+		{
+			name:       "change_action",
+			source:     "[user]\n\tname = {{ .name }}\n",
+			newOutput:  "[user]\n\tname = Other\n",
+			wantSource: "[user]\n\tname = {{ .name }}\n",
+			wantUnresolved: []ReverseTemplateHunk{
+				{
+					Line:     2,
+					OldLines: []string{"\tname = User\n"},
+					NewLines: []string{"\tname = Other\n"},
+				},
+			},
+		},
+		{
+			name:       "change_only_line",
+			source:     "export EDITOR={{ \"vi\" }}\n",
+			newOutput:  "export EDITOR=emacs\n",
+			wantSource: "export EDITOR={{ \"vi\" }}\n",
+			wantUnresolved: []ReverseTemplateHunk{
+				{
+					Line:     1,
+					OldLines: []string{"export EDITOR=vi\n"},
+					NewLines: []string{"export EDITOR=emacs\n"},
+				},
+			},
+		},
+		{
+			name:       "change_range",
+			source:     "{{ range .items }}\nitem\n{{ end }}\nend\n",
+			newOutput:  "\nitem\n\nITEM\n\nEND\n",
+			wantSource: "{{ range .items }}\nitem\n{{ end }}\nEND\n",
+			wantUnresolved: []ReverseTemplateHunk{
+				{
+					Line:     4,
+					OldLines: []string{"item\n"},
+					NewLines: []string{"ITEM\n"},
+				},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := template.New(tc.name).Parse(tc.source)
+			require.NoError(t, err)
+			output := &bytes.Buffer{}
+			require.NoError(t, tmpl.Execute(output, data))
+			ts := NewTargetState(WithTemplateData(data))
+			gotSource, gotUnresolved, err := ts.ReverseTemplate(tc.name, []byte(tc.source), output.Bytes(), []byte(tc.newOutput), ReverseTemplateOptions{
+				AutoTemplateData: tc.autoTemplateData,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSource, string(gotSource))
+			assert.Equal(t, tc.wantUnresolved, gotUnresolved)
+		})
+	}
+}
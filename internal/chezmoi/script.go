@@ -92,7 +92,7 @@ func (s *Script) AppendAllEntries(allEntries []Entry) []Entry {
 
 // Apply runs s.
 func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *ApplyOptions) error {
-	if applyOptions.Ignore(s.targetName) {
+	if applyOptions.Ignore(s.targetName) || !applyOptions.Include.IncludeEntry(s) {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -174,8 +174,8 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 }
 
 // ConcreteValue implements Entry.ConcreteValue.
-func (s *Script) ConcreteValue(ignore func(string) bool, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
-	if ignore(s.targetName) {
+func (s *Script) ConcreteValue(ignore func(string) bool, include *IncludeSet, sourceDir string, umask os.FileMode, recursive bool) (interface{}, error) {
+	if ignore(s.targetName) || !include.IncludeEntry(s) {
 		return nil, nil
 	}
 	contents, err := s.Contents()
@@ -221,8 +221,8 @@ func (s *Script) TargetName() string {
 }
 
 // archive writes s to w.
-func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
-	if ignore(s.targetName) {
+func (s *Script) archive(w *tar.Writer, ignore func(string) bool, include *IncludeSet, headerTemplate *tar.Header, umask os.FileMode) error {
+	if ignore(s.targetName) || !include.IncludeEntry(s) {
 		return nil
 	}
 	contents, err := s.Contents()
@@ -0,0 +1,136 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+// DefaultSecretScannerMinEntropy is the default minimum entropy, in bits per
+// character, of strings that a SecretScanner reports as possible secrets.
+const DefaultSecretScannerMinEntropy = 4.5
+
+var (
+	defaultSecretScannerRules = []secretScannerRule{
+		{
+			name:   "AWS access key ID",
+			regexp: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		},
+		{
+			name:   "AWS secret access key",
+			regexp: regexp.MustCompile(`(?i)aws_secret_access_key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`),
+		},
+		{
+			name:   "private key",
+			regexp: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`),
+		},
+		{
+			name:   "GitHub token",
+			regexp: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`),
+		},
+	}
+	// Only strings of at least 32 characters are checked for high entropy, so
+	// that short identifiers are not reported.
+	secretScannerWordRegexp = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{32,}`)
+)
+
+// A SecretFinding is a possible secret found by a SecretScanner.
+type SecretFinding struct {
+	Line int
+	Rule string
+}
+
+// A SecretScanner scans data for possible secrets.
+type SecretScanner struct {
+	rules      []secretScannerRule
+	minEntropy float64
+}
+
+// A secretScannerRule matches a type of secret.
+type secretScannerRule struct {
+	name   string
+	regexp *regexp.Regexp
+}
+
+// NewSecretScanner returns a new SecretScanner that uses the built-in rules
+// and patterns. Strings with at least minEntropy bits of entropy per character
+// are also reported. If minEntropy is zero then entropy is not checked.
+func NewSecretScanner(patterns []string, minEntropy float64) (*SecretScanner, error) {
+	s := &SecretScanner{
+		rules:      append([]secretScannerRule{}, defaultSecretScannerRules...),
+		minEntropy: minEntropy,
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, secretScannerRule{
+			name:   fmt.Sprintf("match for %q", pattern),
+			regexp: re,
+		})
+	}
+	return s, nil
+}
+
+// Scan returns the possible secrets in data. Binary data, that is data
+// containing NUL bytes, is not scanned.
+func (s *SecretScanner) Scan(data []byte) []SecretFinding {
+	if bytes.IndexByte(data, 0) != -1 {
+		return nil
+	}
+	var findings []SecretFinding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		for _, rule := range s.rules {
+			if rule.regexp.Match(text) {
+				findings = append(findings, SecretFinding{
+					Line: line,
+					Rule: rule.name,
+				})
+			}
+		}
+		if s.minEntropy == 0 {
+			continue
+		}
+		for _, word := range secretScannerWordRegexp.FindAll(text, -1) {
+			if shannonEntropy(word) >= s.minEntropy {
+				findings = append(findings, SecretFinding{
+					Line: line,
+					Rule: "high-entropy string",
+				})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// String returns a string representation of f.
+func (f SecretFinding) String() string {
+	return fmt.Sprintf("line %d: %s", f.Line, f.Rule)
+}
+
+// shannonEntropy returns the Shannon entropy of data in bits per byte.
+func shannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -22,6 +22,7 @@ import (
 var DefaultTemplateOptions = []string{"missingkey=error"}
 
 const (
+	attributesName   = ".chezmoiattributes"
 	ignoreName       = ".chezmoiignore"
 	removeName       = ".chezmoiremove"
 	templatesDirName = ".chezmoitemplates"
@@ -36,6 +37,8 @@ type AddOptions struct {
 	Recursive    bool
 	Template     bool
 	AutoTemplate bool
+	// AutoTemplateOptions are the options used when AutoTemplate is set.
+	AutoTemplateOptions AutoTemplateOptions
 }
 
 // An ImportTAROptions contains options for TargetState.ImportTAR.
@@ -52,18 +55,20 @@ type PopulateOptions struct {
 
 // A TargetState represents the root target state.
 type TargetState struct {
-	DestDir         string
-	Entries         map[string]Entry
-	GPG             *GPG
-	MinVersion      *semver.Version
-	SourceDir       string
-	TargetIgnore    *PatternSet
-	TargetRemove    *PatternSet
-	TemplateData    map[string]interface{}
-	TemplateFuncs   template.FuncMap
-	TemplateOptions []string
-	Templates       map[string]*template.Template
-	Umask           os.FileMode
+	DestDir          string
+	Entries          map[string]Entry
+	GPG              *GPG
+	MinVersion       *semver.Version
+	SourceDir        string
+	TargetAttributes *AttributeSet
+	TargetIgnore     *PatternSet
+	TargetRemove     *PatternSet
+	TemplateData     map[string]interface{}
+	TemplateFuncs    template.FuncMap
+	TemplateOptions  []string
+	Templates        map[string]*template.Template
+	TemplateTrace    *TemplateTrace
+	Umask            os.FileMode
 }
 
 // A TargetStateOption sets an option on a TargeState.
@@ -104,6 +109,13 @@ func WithSourceDir(sourceDir string) TargetStateOption {
 	}
 }
 
+// WithTargetAttributes sets the target attributes.
+func WithTargetAttributes(targetAttributes *AttributeSet) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.TargetAttributes = targetAttributes
+	}
+}
+
 // WithTargetIgnore sets the target patterns to ignore.
 func WithTargetIgnore(targetIgnore *PatternSet) TargetStateOption {
 	return func(ts *TargetState) {
@@ -146,6 +158,13 @@ func WithTemplates(templates map[string]*template.Template) TargetStateOption {
 	}
 }
 
+// WithTemplateTrace sets the template trace.
+func WithTemplateTrace(templateTrace *TemplateTrace) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.TemplateTrace = templateTrace
+	}
+}
+
 // WithUmask sets the umask.
 func WithUmask(umask os.FileMode) TargetStateOption {
 	return func(ts *TargetState) {
@@ -153,13 +172,48 @@ func WithUmask(umask os.FileMode) TargetStateOption {
 	}
 }
 
+// ParseTemplatesDir parses all the files in dir, which is normally the
+// .chezmoitemplates directory, as templates with options and funcs. The
+// templates are returned by their path relative to dir.
+func ParseTemplatesDir(fs vfs.FS, dir string, options []string, funcs template.FuncMap) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+	prefix := filepath.ToSlash(dir) + "/"
+	if err := vfs.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode().IsRegular():
+			contents, err := fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
+			tmpl, err := template.New(name).Option(options...).Funcs(funcs).Parse(string(contents))
+			if err != nil {
+				return err
+			}
+			templates[name] = tmpl
+			return nil
+		case info.IsDir():
+			return nil
+		default:
+			return fmt.Errorf("unsupported file in %s: %s", templatesDirName, path)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
 // NewTargetState creates a new TargetState with the given options.
 func NewTargetState(options ...TargetStateOption) *TargetState {
 	ts := &TargetState{
-		Entries:         make(map[string]Entry),
-		TargetIgnore:    NewPatternSet(),
-		TargetRemove:    NewPatternSet(),
-		TemplateOptions: DefaultTemplateOptions,
+		Entries:          make(map[string]Entry),
+		TargetAttributes: NewAttributeSet(),
+		TargetIgnore:     NewPatternSet(),
+		TargetRemove:     NewPatternSet(),
+		TemplateOptions:  DefaultTemplateOptions,
 	}
 	for _, o := range options {
 		o(ts)
@@ -257,7 +311,7 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 			return err
 		}
 		if addOptions.Template && addOptions.AutoTemplate {
-			contents = autoTemplate(contents, ts.TemplateData)
+			contents, _ = AutoTemplate(contents, ts.TemplateData, addOptions.AutoTemplateOptions)
 		}
 		if addOptions.Encrypt {
 			contents, err = ts.GPG.Encrypt(targetPath, contents)
@@ -348,14 +402,14 @@ func (ts *TargetState) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptio
 }
 
 // Archive writes ts to w.
-func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
+func (ts *TargetState) Archive(w *tar.Writer, include *IncludeSet, umask os.FileMode) error {
 	headerTemplate, err := ts.getTarHeaderTemplate()
 	if err != nil {
 		return err
 	}
 
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, headerTemplate, umask); err != nil {
+		if err := ts.Entries[entryName].archive(w, ts.TargetIgnore.Match, include, headerTemplate, umask); err != nil {
 			return err
 		}
 	}
@@ -363,10 +417,10 @@ func (ts *TargetState) Archive(w *tar.Writer, umask os.FileMode) error {
 }
 
 // ConcreteValue returns a value suitable for serialization.
-func (ts *TargetState) ConcreteValue(recursive bool) (interface{}, error) {
+func (ts *TargetState) ConcreteValue(include *IncludeSet, recursive bool) (interface{}, error) {
 	var entryConcreteValues []interface{}
 	for _, entryName := range sortedEntryNames(ts.Entries) {
-		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.TargetIgnore.Match, ts.SourceDir, ts.Umask, recursive)
+		entryConcreteValue, err := ts.Entries[entryName].ConcreteValue(ts.TargetIgnore.Match, include, ts.SourceDir, ts.Umask, recursive)
 		if err != nil {
 			return nil, err
 		}
@@ -393,12 +447,19 @@ func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, er
 	if err != nil {
 		return nil, err
 	}
-	for name, t := range ts.Templates {
-		tmpl, err = tmpl.AddParseTree(name, t.Tree)
-		if err != nil {
+	for templateName, t := range ts.Templates {
+		tree := t.Tree
+		if ts.TemplateTrace != nil {
+			// Tracing modifies parse trees, so copy shared trees.
+			tree = tree.Copy()
+		}
+		if _, err := tmpl.AddParseTree(templateName, tree); err != nil {
 			return nil, err
 		}
 	}
+	if ts.TemplateTrace != nil {
+		ts.TemplateTrace.instrument(name, tmpl, ts.TemplateFuncs)
+	}
 	sb := &strings.Builder{}
 	if err = tmpl.ExecuteTemplate(sb, name, ts.TemplateData); err != nil {
 		return nil, err
@@ -446,7 +507,19 @@ func (ts *TargetState) ImportTAR(r *tar.Reader, importTAROptions ImportTAROption
 
 // Populate walks fs from ts.SourceDir to populate ts.
 func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
-	return vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
+	// Parse the templates in the .chezmoitemplates directory first, as other
+	// special files, like .chezmoiignore, are executed as templates before the
+	// walk reaches it.
+	templatesDir := filepath.Join(ts.SourceDir, templatesDirName)
+	if info, err := fs.Stat(templatesDir); err == nil && info.IsDir() {
+		if err := ts.addTemplatesDir(fs, templatesDir); err != nil {
+			return err
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, _ error) error {
 		relPath, err := filepath.Rel(ts.SourceDir, path)
 		if err != nil {
 			return err
@@ -457,6 +530,9 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		// Treat all files and directories beginning with "." specially.
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
+			case info.Name() == attributesName:
+				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				return ts.addAttributes(fs, path, filepath.Join(dns...))
 			case info.Name() == ignoreName:
 				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
 				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
@@ -464,8 +540,10 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
 				return ts.addPatterns(fs, ts.TargetRemove, path, filepath.Join(dns...))
 			case info.Name() == templatesDirName:
-				if err := ts.addTemplatesDir(fs, path); err != nil {
-					return err
+				if path != templatesDir {
+					if err := ts.addTemplatesDir(fs, path); err != nil {
+						return err
+					}
 				}
 				return filepath.SkipDir
 			case info.Name() == versionName:
@@ -582,7 +660,42 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 			return fmt.Errorf("%s: unsupported file type", path)
 		}
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	ts.applyTargetAttributes()
+	return nil
+}
+
+// addAttributes adds the attributes in the file at path to ts.TargetAttributes.
+func (ts *TargetState) addAttributes(fs vfs.FS, path, relPath string) error {
+	data, err := ts.executeTemplate(fs, path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(relPath)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		text := s.Text()
+		if index := strings.IndexRune(text, '#'); index != -1 {
+			text = text[:index]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		ownership, err := parseAttributes(fields[1:])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := ts.TargetAttributes.Add(filepath.Join(dir, fields[0]), ownership); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
 }
 
 func (ts *TargetState) addDir(targetName string, entries map[string]Entry, parentDirSourceName string, exact bool, perm os.FileMode, createKeepFile bool, mutator Mutator) error {
@@ -740,33 +853,30 @@ func (ts *TargetState) addSymlink(targetName string, entries map[string]Entry, p
 }
 
 func (ts *TargetState) addTemplatesDir(fs vfs.FS, path string) error {
-	prefix := filepath.ToSlash(path) + "/"
-	return vfs.Walk(fs, path, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	templates, err := ParseTemplatesDir(fs, path, ts.TemplateOptions, ts.TemplateFuncs)
+	if err != nil {
+		return err
+	}
+	for name, tmpl := range templates {
+		if ts.Templates == nil {
+			ts.Templates = make(map[string]*template.Template)
 		}
-		switch {
-		case info.Mode().IsRegular():
-			contents, err := fs.ReadFile(path)
-			if err != nil {
-				return err
-			}
-			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
-			tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(contents))
-			if err != nil {
-				return err
-			}
-			if ts.Templates == nil {
-				ts.Templates = make(map[string]*template.Template)
-			}
-			ts.Templates[name] = tmpl
-			return nil
-		case info.IsDir():
-			return nil
-		default:
-			return fmt.Errorf("unsupported file in %s: %s", templatesDirName, path)
+		ts.Templates[name] = tmpl
+	}
+	return nil
+}
+
+// applyTargetAttributes sets the ownership of all files and directories in ts
+// from ts.TargetAttributes.
+func (ts *TargetState) applyTargetAttributes() {
+	for _, entry := range ts.AllEntries() {
+		switch entry := entry.(type) {
+		case *Dir:
+			entry.Ownership = ts.TargetAttributes.Match(entry.targetName)
+		case *File:
+			entry.Ownership = ts.TargetAttributes.Match(entry.targetName)
 		}
-	})
+	}
 }
 
 func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
@@ -114,7 +114,7 @@ func TestEndToEnd(t *testing.T) {
 				Stdout:            os.Stdout,
 				Umask:             0o22,
 			}
-			assert.NoError(t, ts.Apply(fs, NewVerboseMutator(os.Stderr, NewFSMutator(fs), false, 0), tc.follow, applyOptions))
+			assert.NoError(t, ts.Apply(fs, NewVerboseMutator(os.Stderr, NewFSMutator(fs), false, 0, nil), tc.follow, applyOptions))
 			vfst.RunTests(t, fs, "", tc.tests)
 		})
 	}
@@ -471,6 +471,27 @@ func TestTargetStatePopulate(t *testing.T) {
 				}),
 			),
 		},
+		{
+			name: "ignore_template_dir",
+			root: map[string]interface{}{
+				"/.chezmoiignore":        "{{ template \"foo\" }}\n",
+				"/.chezmoitemplates/foo": "f*",
+			},
+			sourceDir: "/",
+			want: NewTargetState(
+				WithDestDir("/"),
+				WithSourceDir("/"),
+				WithTargetIgnore(&PatternSet{
+					includes: map[string]struct{}{
+						"f*": {},
+					},
+					excludes: map[string]struct{}{},
+				}),
+				WithTemplates(map[string]*template.Template{
+					"foo": template.Must(template.New("foo").Option("missingkey=error").Parse("f*")),
+				}),
+			),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := vfst.NewTestFS(tc.root)
@@ -0,0 +1,334 @@
+package chezmoi
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+const (
+	templateTraceFuncName      = "_chezmoiTrace"
+	templateTraceMaxValueWidth = 64
+	templateTraceRedacted      = "<redacted>"
+)
+
+// A TemplateTrace records the execution of templates: the branches taken, the
+// data keys used, the functions called, and the templates included. It is safe
+// for concurrent use.
+type TemplateTrace struct {
+	isSecretFunc func(string) bool
+	secrets      *Redactor
+	mutex        sync.Mutex
+	events       map[string][]templateTraceEvent
+}
+
+// A templateTraceEvent is a single event in the execution of a template.
+type templateTraceEvent struct {
+	line int
+	text string
+}
+
+// A templateTraceInstrumenter adds trace actions to a template's parse trees.
+type templateTraceInstrumenter struct {
+	events       []templateTraceEvent
+	dollarIsData bool
+}
+
+// NewTemplateTrace returns a new TemplateTrace. The values returned by
+// functions for which isSecretFunc returns true, and by functions called with
+// arguments that contain those values, are not recorded, and are masked
+// wherever they appear in the arguments of other functions.
+func NewTemplateTrace(isSecretFunc func(string) bool) *TemplateTrace {
+	return &TemplateTrace{
+		isSecretFunc: isSecretFunc,
+		secrets:      NewRedactor(),
+		events:       make(map[string][]templateTraceEvent),
+	}
+}
+
+// WriteReport writes a report of all the templates executed to w, ordered by
+// template name.
+func (t *TemplateTrace) WriteReport(w io.Writer) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	names := make([]string, 0, len(t.events))
+	for name := range t.events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s:\n", name); err != nil {
+			return err
+		}
+		events := t.events[name]
+		for i := 0; i < len(events); {
+			// Combine consecutive identical events, for example from
+			// iterations of range.
+			n := 1
+			for i+n < len(events) && events[i+n] == events[i] {
+				n++
+			}
+			text := events[i].text
+			if n > 1 {
+				text += fmt.Sprintf(" (%d times)", n)
+			}
+			var err error
+			if events[i].line == 0 {
+				_, err = fmt.Fprintf(w, "  %s\n", text)
+			} else {
+				_, err = fmt.Fprintf(w, "  line %d: %s\n", events[i].line, text)
+			}
+			if err != nil {
+				return err
+			}
+			i += n
+		}
+	}
+	return nil
+}
+
+// instrument modifies tmpl, which will be executed as name, so that its
+// execution is recorded in t. funcs are the template functions that tmpl was
+// parsed with. tmpl's parse trees are modified, so they must not be shared
+// with other templates.
+func (t *TemplateTrace) instrument(name string, tmpl *template.Template, funcs template.FuncMap) {
+	t.mutex.Lock()
+	if _, ok := t.events[name]; !ok {
+		t.events[name] = nil
+	}
+	t.mutex.Unlock()
+
+	ti := &templateTraceInstrumenter{}
+	for _, associatedTmpl := range tmpl.Templates() {
+		if associatedTmpl.Tree == nil || associatedTmpl.Tree.Root == nil {
+			continue
+		}
+		// Only the top level template's data is the template data.
+		ti.dollarIsData = associatedTmpl.Name() == name
+		ti.instrumentList(associatedTmpl.Tree.Root, ti.dollarIsData)
+	}
+
+	tracedFuncs := make(template.FuncMap, len(funcs)+1)
+	for funcName, f := range funcs {
+		tracedFuncs[funcName] = t.traceFunc(name, funcName, f)
+	}
+	tracedFuncs[templateTraceFuncName] = func(index int) string {
+		t.record(name, ti.events[index])
+		return ""
+	}
+	tmpl.Funcs(tracedFuncs)
+}
+
+// record records event in the execution of the template name.
+func (t *TemplateTrace) record(name string, event templateTraceEvent) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.events[name] = append(t.events[name], event)
+}
+
+// traceFunc returns a function with the same type as f that records each call
+// to f in the execution of the template name.
+func (t *TemplateTrace) traceFunc(name, funcName string, f interface{}) interface{} {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func {
+		return f
+	}
+	return reflect.MakeFunc(fv.Type(), func(args []reflect.Value) (results []reflect.Value) {
+		argStrs := make([]string, 0, len(args))
+		secretArgs := false
+		appendArgStr := func(arg reflect.Value) {
+			argStr, secret := t.formatValue(arg)
+			argStrs = append(argStrs, argStr)
+			secretArgs = secretArgs || secret
+		}
+		for i, arg := range args {
+			if fv.Type().IsVariadic() && i == len(args)-1 {
+				for j := 0; j < arg.Len(); j++ {
+					appendArgStr(arg.Index(j))
+				}
+			} else {
+				appendArgStr(arg)
+			}
+		}
+		call := strings.Join(append([]string{funcName}, argStrs...), " ")
+		defer func() {
+			if r := recover(); r != nil {
+				t.record(name, templateTraceEvent{text: fmt.Sprintf("call %s: error: %v", call, r)})
+				panic(r)
+			}
+			var result string
+			switch {
+			case len(results) == 0:
+				t.record(name, templateTraceEvent{text: "call " + call})
+				return
+			case len(results) == 2 && !results[1].IsNil():
+				t.record(name, templateTraceEvent{text: fmt.Sprintf("call %s: error: %v", call, results[1].Interface())})
+				return
+			case secretArgs || t.isSecretFunc != nil && t.isSecretFunc(funcName):
+				// The result is, or may be derived from, a secret.
+				if results[0].CanInterface() {
+					t.secrets.AddValue(results[0].Interface())
+				}
+				result = templateTraceRedacted
+			default:
+				result, _ = t.formatValue(results[0])
+			}
+			t.record(name, templateTraceEvent{text: fmt.Sprintf("call %s = %s", call, result)})
+		}()
+		if fv.Type().IsVariadic() {
+			return fv.CallSlice(args)
+		}
+		return fv.Call(args)
+	}).Interface()
+}
+
+// formatValue returns a short string representation of value with any secrets
+// recorded by t masked, and whether value contained any secrets.
+func (t *TemplateTrace) formatValue(value reflect.Value) (string, bool) {
+	if !value.IsValid() || !value.CanInterface() {
+		return formatTemplateTraceValue(value), false
+	}
+	valueStr := formatTemplateTraceValue(reflect.ValueOf(value.Interface()))
+	redactedValueStr := formatTemplateTraceValue(reflect.ValueOf(t.secrets.RedactValue(value.Interface())))
+	return redactedValueStr, redactedValueStr != valueStr
+}
+
+// instrumentList adds trace actions to list and its children. dotIsData is
+// true if dot is the template data.
+func (ti *templateTraceInstrumenter) instrumentList(list *parse.ListNode, dotIsData bool) {
+	if list == nil {
+		return
+	}
+	nodes := make([]parse.Node, 0, len(list.Nodes))
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.ActionNode:
+			nodes = ti.appendDataKeysAction(nodes, n.Line, n.Pipe, dotIsData)
+		case *parse.IfNode:
+			nodes = ti.appendDataKeysAction(nodes, n.Line, n.Pipe, dotIsData)
+			ti.prependAction(n.List, n.Line, "if: true")
+			ti.instrumentList(n.List, dotIsData)
+			ti.prependAction(n.ElseList, n.Line, "if: else")
+			ti.instrumentList(n.ElseList, dotIsData)
+		case *parse.RangeNode:
+			nodes = ti.appendDataKeysAction(nodes, n.Line, n.Pipe, dotIsData)
+			ti.prependAction(n.List, n.Line, "range: iteration")
+			ti.instrumentList(n.List, false)
+			ti.prependAction(n.ElseList, n.Line, "range: else")
+			ti.instrumentList(n.ElseList, dotIsData)
+		case *parse.TemplateNode:
+			nodes = ti.appendDataKeysAction(nodes, n.Line, n.Pipe, dotIsData)
+			nodes = append(nodes, ti.newAction(n.Line, "template "+strconv.Quote(n.Name)))
+		case *parse.WithNode:
+			nodes = ti.appendDataKeysAction(nodes, n.Line, n.Pipe, dotIsData)
+			ti.prependAction(n.List, n.Line, "with: true")
+			ti.instrumentList(n.List, false)
+			ti.prependAction(n.ElseList, n.Line, "with: else")
+			ti.instrumentList(n.ElseList, dotIsData)
+		}
+		nodes = append(nodes, node)
+	}
+	list.Nodes = nodes
+}
+
+// appendDataKeysAction appends an action that records the data keys used by
+// pipe to nodes, if pipe uses any data keys.
+func (ti *templateTraceInstrumenter) appendDataKeysAction(nodes []parse.Node, line int, pipe *parse.PipeNode, dotIsData bool) []parse.Node {
+	var dataKeys []string
+	appendTemplateDataKeys(&dataKeys, pipe, dotIsData, ti.dollarIsData)
+	if len(dataKeys) == 0 {
+		return nodes
+	}
+	return append(nodes, ti.newAction(line, "data "+strings.Join(dataKeys, " ")))
+}
+
+// newAction returns a new action that records an event with line and text.
+func (ti *templateTraceInstrumenter) newAction(line int, text string) *parse.ActionNode {
+	index := len(ti.events)
+	ti.events = append(ti.events, templateTraceEvent{
+		line: line,
+		text: text,
+	})
+	return &parse.ActionNode{
+		NodeType: parse.NodeAction,
+		Line:     line,
+		Pipe: &parse.PipeNode{
+			NodeType: parse.NodePipe,
+			Line:     line,
+			Cmds: []*parse.CommandNode{
+				{
+					NodeType: parse.NodeCommand,
+					Args: []parse.Node{
+						parse.NewIdentifier(templateTraceFuncName),
+						&parse.NumberNode{
+							NodeType: parse.NodeNumber,
+							IsInt:    true,
+							Int64:    int64(index),
+							Text:     strconv.Itoa(index),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// prependAction adds an action that records an event with line and text to
+// the start of list.
+func (ti *templateTraceInstrumenter) prependAction(list *parse.ListNode, line int, text string) {
+	if list == nil {
+		return
+	}
+	list.Nodes = append([]parse.Node{ti.newAction(line, text)}, list.Nodes...)
+}
+
+// appendTemplateDataKeys appends the data keys used by node to dataKeys.
+// dotIsData and dollarIsData are true if dot and $ are the template data.
+func appendTemplateDataKeys(dataKeys *[]string, node parse.Node, dotIsData, dollarIsData bool) {
+	switch n := node.(type) {
+	case *parse.ChainNode:
+		appendTemplateDataKeys(dataKeys, n.Node, dotIsData, dollarIsData)
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			appendTemplateDataKeys(dataKeys, arg, dotIsData, dollarIsData)
+		}
+	case *parse.FieldNode:
+		if dotIsData {
+			*dataKeys = append(*dataKeys, "."+strings.Join(n.Ident, "."))
+		}
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			appendTemplateDataKeys(dataKeys, cmd, dotIsData, dollarIsData)
+		}
+	case *parse.VariableNode:
+		if dollarIsData && len(n.Ident) > 1 && n.Ident[0] == "$" {
+			*dataKeys = append(*dataKeys, "."+strings.Join(n.Ident[1:], "."))
+		}
+	}
+}
+
+// formatTemplateTraceValue returns a short string representation of value.
+func formatTemplateTraceValue(value reflect.Value) string {
+	if !value.IsValid() {
+		return "<nil>"
+	}
+	var s string
+	if value.Kind() == reflect.String {
+		s = strconv.Quote(value.String())
+	} else {
+		s = fmt.Sprintf("%v", value.Interface())
+	}
+	if len(s) > templateTraceMaxValueWidth {
+		s = s[:templateTraceMaxValueWidth-3] + "..."
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package chezmoi
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateTrace(t *testing.T) {
+	templateTrace := NewTemplateTrace(func(name string) bool {
+		return name == "password"
+	})
+	funcs := template.FuncMap{
+		"password": func(id string) string {
+			return "hunter2"
+		},
+		"quote": strconv.Quote,
+		"upper": strings.ToUpper,
+	}
+	partial, err := template.New("partial").Funcs(funcs).Parse(`{{ upper . }}`)
+	require.NoError(t, err)
+	ts := NewTargetState(
+		WithTemplateData(map[string]interface{}{
+			"email": "john@home.org",
+			"hosts": []interface{}{"alpha", "beta"},
+			"work":  false,
+		}),
+		WithTemplateFuncs(funcs),
+		WithTemplates(map[string]*template.Template{
+			"partial": partial,
+		}),
+		WithTemplateTrace(templateTrace),
+	)
+
+	output, err := ts.ExecuteTemplateData("dot_netrc.tmpl", []byte(strings.Join([]string{
+		`{{ if .work }}work{{ else }}home{{ end }}`,
+		`{{ range .hosts }}{{ . }} {{ end }}`,
+		`{{ password .email }}`,
+		`{{ template "partial" .email }}`,
+		`{{ password .email | upper | quote }}`,
+	}, "\n")))
+	require.NoError(t, err)
+	assert.Equal(t, "home\nalpha beta \nhunter2\nJOHN@HOME.ORG\n\"HUNTER2\"", string(output))
+
+	sb := &strings.Builder{}
+	require.NoError(t, templateTrace.WriteReport(sb))
+	assert.Equal(t, strings.Join([]string{
+		"dot_netrc.tmpl:",
+		"  line 1: data .work",
+		"  line 1: if: else",
+		"  line 2: data .hosts",
+		"  line 2: range: iteration (2 times)",
+		"  line 3: data .email",
+		`  call password "john@home.org" = <redacted>`,
+		"  line 4: data .email",
+		`  line 4: template "partial"`,
+		`  call upper "john@home.org" = "JOHN@HOME.ORG"`,
+		"  line 5: data .email",
+		`  call password "john@home.org" = <redacted>`,
+		`  call upper "<redacted>" = <redacted>`,
+		`  call quote "<redacted>" = <redacted>`,
+		"",
+	}, "\n"), sb.String())
+
+	// Check that shared templates were not modified.
+	assert.Equal(t, `{{upper .}}`, partial.Tree.Root.String())
+}
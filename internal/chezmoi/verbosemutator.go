@@ -22,15 +22,18 @@ type VerboseMutator struct {
 	w               io.Writer
 	colored         bool
 	maxDiffDataSize int
+	redactor        *Redactor
 }
 
-// NewVerboseMutator returns a new VerboseMutator.
-func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int) *VerboseMutator {
+// NewVerboseMutator returns a new VerboseMutator. Secrets recorded by redactor
+// are masked in the output.
+func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int, redactor *Redactor) *VerboseMutator {
 	return &VerboseMutator{
 		m:               m,
 		w:               w,
 		colored:         colored,
 		maxDiffDataSize: maxDiffDataSize,
+		redactor:        redactor,
 	}
 }
 
@@ -39,9 +42,21 @@ func (m *VerboseMutator) Chmod(name string, mode os.FileMode) error {
 	action := fmt.Sprintf("chmod %o %s", mode, MaybeShellQuote(name))
 	err := m.m.Chmod(name, mode)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
+	}
+	return err
+}
+
+// Chown implements Mutator.Chown.
+func (m *VerboseMutator) Chown(name string, uid, gid int) error {
+	action := fmt.Sprintf("chown %d:%d %s", uid, gid, MaybeShellQuote(name))
+	err := m.m.Chown(name, uid, gid)
+	if err == nil {
+		m.println(action)
+	} else {
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -51,7 +66,7 @@ func (m *VerboseMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
 	action := cmdString(cmd)
 	output, err := m.m.IdempotentCmdOutput(cmd)
 	if err != nil {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return output, err
 }
@@ -61,9 +76,9 @@ func (m *VerboseMutator) Mkdir(name string, perm os.FileMode) error {
 	action := fmt.Sprintf("mkdir -m %o %s", perm, MaybeShellQuote(name))
 	err := m.m.Mkdir(name, perm)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -73,9 +88,9 @@ func (m *VerboseMutator) RemoveAll(name string) error {
 	action := fmt.Sprintf("rm -rf %s", MaybeShellQuote(name))
 	err := m.m.RemoveAll(name)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -85,9 +100,9 @@ func (m *VerboseMutator) Rename(oldpath, newpath string) error {
 	action := fmt.Sprintf("mv %s %s", MaybeShellQuote(oldpath), MaybeShellQuote(newpath))
 	err := m.m.Rename(oldpath, newpath)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -97,9 +112,9 @@ func (m *VerboseMutator) RunCmd(cmd *exec.Cmd) error {
 	action := cmdString(cmd)
 	err := m.m.RunCmd(cmd)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -114,7 +129,7 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 	action := fmt.Sprintf("install -m %o /dev/null %s", perm, MaybeShellQuote(name))
 	err := m.m.WriteFile(name, data, perm, currData)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 		// Don't print diffs if either file is binary.
 		if isBinary(currData) || isBinary(data) {
 			return nil
@@ -125,11 +140,11 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 				return nil
 			}
 		}
-		aLines, err := splitLines(currData)
+		aLines, err := splitLines(m.redactor.Redact(currData))
 		if err != nil {
 			return err
 		}
-		bLines, err := splitLines(data)
+		bLines, err := splitLines(m.redactor.Redact(data))
 		if err != nil {
 			return err
 		}
@@ -148,7 +163,7 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 			return err
 		}
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
@@ -158,13 +173,18 @@ func (m *VerboseMutator) WriteSymlink(oldname, newname string) error {
 	action := fmt.Sprintf("ln -sf %s %s", MaybeShellQuote(oldname), MaybeShellQuote(newname))
 	err := m.m.WriteSymlink(oldname, newname)
 	if err == nil {
-		_, _ = fmt.Fprintln(m.w, action)
+		m.println(action)
 	} else {
-		_, _ = fmt.Fprintf(m.w, "%s: %v\n", action, err)
+		m.println(fmt.Sprintf("%s: %v", action, err))
 	}
 	return err
 }
 
+// println writes s, with secrets masked, and a newline to m.w.
+func (m *VerboseMutator) println(s string) {
+	_, _ = fmt.Fprintln(m.w, m.redactor.RedactString(s))
+}
+
 // cmdString returns a string representation of cmd.
 func cmdString(cmd *exec.Cmd) string {
 	s := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))